@@ -19,24 +19,33 @@ func (a *App) Listen(port string) error {
 	if a.recalculateMux {
 
 		nestedMux, totalRoutes := buildMux(a.startRoute, a.startRoute.mws, 0)
-		if !a.config.DisableGenerateTS {
-			generateTs(a)
+		if err := a.WriteClients(); err != nil {
+			// the clients are a convenience for development, a server that can not write them (on a read only file system for example) still serves
+			fmt.Fprintln(os.Stderr, DefaultColors.Red+"could not write the clients : "+err.Error()+DefaultColors.Reset)
 		}
 		a.serveMux = http.NewServeMux()
 		a.serveMux.Handle("/", nestedMux)
-
+		if a.config.WebSocketPath != "" {
+			a.serveMux.Handle(a.config.WebSocketPath, a.webSocketHandler())
+		}
+		if a.config.BatchPath != "" {
+			a.serveMux.Handle(a.config.BatchPath, a.batchHandler())
+		}
+		if a.config.OpenAPIPath != "" {
+			doc, err := a.OpenAPI()
+			if err != nil {
+				return err
+			}
+			a.serveMux.Handle(a.config.OpenAPIPath, a.openAPIHandler(doc))
+		}
 		if a.config.EnablePProf {
 			attachPprofRoutes(a.serveMux)
 		}
 		if !a.config.DisableInfoPrinting {
-			var serverUrl string
-			if a.config.ServerURL == "" {
-				serverUrl = "http://127.0.0.1"
-			} else {
-				serverUrl = a.config.ServerURL
+			serverUrl := a.config.ServerURL
+			if serverUrl == "" {
+				serverUrl = "http://127.0.0.1" + port
 			}
-
-			serverUrl += port
 			printStartServerInfo(totalRoutes, serverUrl)
 			a.PrintRoutes()
 		}
@@ -97,7 +106,7 @@ func buildMux(router *Router, prevMws []Handler, totalRoutes int) (*http.ServeMu
 	}
 
 	for path, proc := range router.procedures {
-		attachProcedureToMux(mux, path, proc, router.mws)
+		attachProcedureToMux(mux, path, proc, router.mws, router.app)
 	}
 
 	return mux, totalRoutes
@@ -3,6 +3,7 @@ package bluerpc
 import (
 	"fmt"
 	"net/http"
+	"reflect"
 	"sync"
 )
 
@@ -16,6 +17,15 @@ type App struct {
 	server         *http.Server
 	mutex          sync.Mutex
 	recalculateMux bool
+
+	// the typescript types set with OverrideTSType
+	tsTypeOverrides map[reflect.Type]string
+
+	// the values of every type registered with RegisterEnum
+	enums map[reflect.Type][]any
+
+	// the generators added with AddGenerator
+	generators []pluginGenerator
 }
 
 func New(blueConfig ...*Config) *App {
@@ -23,10 +33,12 @@ func New(blueConfig ...*Config) *App {
 	cfg := setAppDefaults(blueConfig)
 
 	newApp := App{
-		config:         cfg,
-		serveMux:       http.NewServeMux(),
-		mutex:          sync.Mutex{},
-		recalculateMux: true,
+		config:          cfg,
+		serveMux:        http.NewServeMux(),
+		mutex:           sync.Mutex{},
+		recalculateMux:  true,
+		tsTypeOverrides: map[reflect.Type]string{},
+		enums:           map[reflect.Type][]any{},
 	}
 
 	mws := []Handler{}
@@ -63,11 +75,15 @@ func setAppDefaults(blueConfig []*Config) *Config {
 
 	var (
 		tsOutputPath = "./output.ts"
+		maxBatchSize = 50
 	)
 
 	if cfg.OutputPath == "" {
 		cfg.OutputPath = tsOutputPath
 	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = maxBatchSize
+	}
 	if cfg.ErrorMiddleware == nil {
 		cfg.ErrorMiddleware = DefaultErrorMiddleware
 
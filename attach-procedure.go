@@ -3,6 +3,7 @@ package bluerpc
 import (
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
 )
 
@@ -53,6 +54,11 @@ func (proc *Procedure[query, input, output]) Attach(route Route, slug string) {
 	// dynamicSlugs := findDynamicSlugs(slug)
 	fullRoute := absPath + slug
 	fullHandler := func(c *Ctx) error {
+		if proc.docs.deprecated {
+			if deprecation, ok := proc.docs.deprecationHeader(); ok {
+				c.Set("Deprecation", deprecation)
+			}
+		}
 
 		query, err := validateQuery(c, proc, slug)
 		if err != nil {
@@ -61,6 +67,8 @@ func (proc *Procedure[query, input, output]) Attach(route Route, slug string) {
 		var res *Res[output]
 
 		switch proc.method {
+		case SUBSCRIPTION:
+			return serveSubscription(c, proc, query, fullRoute)
 		case QUERY:
 			res, err = proc.queryHandler(c, query)
 			if err != nil {
@@ -93,6 +101,16 @@ func (proc *Procedure[query, input, output]) Attach(route Route, slug string) {
 		return sendRes(c, res)
 	}
 
+	enumTypes := []reflect.Type{getType(new(query)), getType(new(input)), getType(new(output))}
+	for _, procErr := range proc.errors {
+		if procErr.dataSchema != nil {
+			enumTypes = append(enumTypes, getType(procErr.dataSchema))
+		}
+	}
+	if err := checkEnumTags(enumTypes...); err != nil {
+		panic(fmt.Sprintf("the procedure %s can not be attached : %s", fullRoute, err.Error()))
+	}
+
 	route.addProcedure(slug, &ProcedureInfo{
 		method:      proc.method,
 		handler:     fullHandler,
@@ -103,6 +121,9 @@ func (proc *Procedure[query, input, output]) Attach(route Route, slug string) {
 		outputSchema: new(output),
 		protected:    proc.protected,
 		authorizer:   proc.authorizer,
+		errors:       proc.errors,
+		docs:         proc.docs,
+		verb:         proc.verb,
 	})
 	app := route.getApp()
 
@@ -119,6 +140,9 @@ func validateQuery[query any, input any, output any](c *Ctx, proc *Procedure[que
 	if err := c.queryParser(queryParamInstance, slug); err != nil {
 		return *queryParamInstance, err
 	}
+	if err := c.validateEnums(queryParamInstance); err != nil {
+		return *queryParamInstance, err
+	}
 
 	validatorFn := *proc.validatorFn
 	if validatorFn == nil {
@@ -144,6 +168,9 @@ func validateInput[query any, input any, output any](c *Ctx, proc *Procedure[que
 	if err := c.bodyParser(inputInstance); err != nil {
 		return *inputInstance, err
 	}
+	if err := c.validateEnums(inputInstance); err != nil {
+		return *inputInstance, err
+	}
 
 	validatorFn := *proc.validatorFn
 	if validatorFn == nil {
@@ -0,0 +1,118 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// the number of calls of a batch that are served at the same time
+const batchConcurrency = 8
+
+// creates the handler of the batch endpoint. The body of the request is an array of calls, every call is served concurrently through the app's mux (so each one runs its own middlewares and authorizers)
+// and the response is an array with the result of each call in the same order
+func (a *App) batchHandler() http.Handler {
+	var cors Handler
+	if a.config.CORS_Origin != "" {
+		cors = createDefaultCorsOrigin(a.config.CORS_Origin)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := createCtx(w, r, a)
+		if cors != nil {
+			cors(ctx)
+		}
+		if r.Method != http.MethodPost {
+			ctx.status(http.StatusMethodNotAllowed).jSON(Map{"message": "Method not allowed"})
+			return
+		}
+
+		var calls []procedureCall
+		if err := json.NewDecoder(r.Body).Decode(&calls); err != nil {
+			ctx.status(http.StatusBadRequest).jSON(Map{"message": "invalid batch : " + err.Error()})
+			return
+		}
+		if len(calls) > a.config.MaxBatchSize {
+			ctx.status(http.StatusBadRequest).jSON(Map{"message": fmt.Sprintf("a batch can not hold more than %d calls", a.config.MaxBatchSize)})
+			return
+		}
+
+		results := make([]*procedureCallResult, len(calls))
+		var wg sync.WaitGroup
+		running := make(chan struct{}, batchConcurrency)
+		for i := range calls {
+			wg.Add(1)
+			running <- struct{}{}
+			go func(i int) {
+				defer func() {
+					<-running
+					wg.Done()
+				}()
+				results[i] = a.serveBatchCall(r, &calls[i])
+			}(i)
+		}
+		wg.Wait()
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			ctx.status(http.StatusInternalServerError).jSON(Map{"message": err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", ApplicationJSON)
+		w.Write(data)
+	})
+}
+
+// a call can not target the batch or the websocket endpoint, nesting them would multiply the work of a single request
+func (a *App) checkCallPath(req *http.Request) error {
+	callPath := path.Clean(req.URL.Path)
+	for _, transportPath := range []string{a.config.BatchPath, a.config.WebSocketPath} {
+		if transportPath != "" && callPath == path.Clean(transportPath) {
+			return fmt.Errorf("%s can not be called from a batch or a websocket", transportPath)
+		}
+	}
+	return nil
+}
+
+func (a *App) serveBatchCall(parent *http.Request, call *procedureCall) *procedureCallResult {
+	if call.Type == SUBSCRIPTION {
+		return &procedureCallResult{
+			Id:     call.Id,
+			Type:   "error",
+			Status: http.StatusBadRequest,
+			Body:   Map{"message": "subscriptions cannot be batched"},
+		}
+	}
+	req, err := newCallRequest(parent.Context(), parent, call)
+	if err == nil {
+		err = a.checkCallPath(req)
+	}
+	if err != nil {
+		return &procedureCallResult{
+			Id:     call.Id,
+			Type:   "error",
+			Status: http.StatusBadRequest,
+			Body:   Map{"message": err.Error()},
+		}
+	}
+
+	writer := newCallWriter()
+	a.serveMux.ServeHTTP(writer, req)
+
+	result := &procedureCallResult{
+		Id:      call.Id,
+		Type:    "result",
+		Status:  writer.statusCode(),
+		Headers: map[string]string{},
+		Body:    decodeCallBody(writer.header.Get("Content-Type"), writer.body.Bytes()),
+	}
+	if result.Status >= http.StatusBadRequest {
+		result.Type = "error"
+	}
+	for key := range writer.header {
+		result.Headers[key] = writer.header.Get(key)
+	}
+	return result
+}
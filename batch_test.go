@@ -0,0 +1,120 @@
+package bluerpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestBatch(t *testing.T) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	fmt.Println(DefaultColors.Green + "TESTING BATCHED CALLS" + DefaultColors.Reset)
+	app := New(&Config{
+		ValidatorFn:         validate.Struct,
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		BatchPath:           "/batch",
+		MaxBatchSize:        4,
+	})
+
+	NewQuery(app, func(ctx *Ctx, query test_query) (*Res[procedure_test_output], error) {
+		return &Res[procedure_test_output]{
+			Body: procedure_test_output{
+				FieldOneOut:   query.QueryFirst,
+				FieldTwoOut:   "dwadwa",
+				FieldThreeOut: "dwadwadwa",
+			},
+		}, nil
+	}).Attach(app, "/query")
+
+	NewMutation(app, func(ctx *Ctx, query any, input procedure_test_input) (*Res[procedure_test_output], error) {
+		return &Res[procedure_test_output]{
+			Body: procedure_test_output{
+				FieldOneOut:   input.House,
+				FieldThreeOut: "dwadwadwa",
+			},
+		}, nil
+	}).Attach(app, "/mutation")
+	NewMutation(app, func(ctx *Ctx, query any, input procedure_test_input) (*Res[procedure_test_output], error) {
+		return &Res[procedure_test_output]{
+			Body: procedure_test_output{
+				FieldOneOut:   input.House,
+				FieldThreeOut: "dwadwadwa",
+			},
+		}, nil
+	}).HTTPMethod(http.MethodPatch).Attach(app, "/patch")
+
+	calls := []procedureCall{
+		{Type: QUERY, Path: "/query?query=first"},
+		{Type: MUTATION, Path: "/mutation", Input: json.RawMessage(`{"House":"second"}`)},
+		{Type: MUTATION, Method: http.MethodPatch, Path: "/patch", Input: json.RawMessage(`{"House":"third"}`)},
+		{Type: QUERY, Path: "/query"},
+	}
+	jsonData, err := json.Marshal(calls)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Error marshaling JSON: %s", err.Error())
+	}
+	req, err := http.NewRequest("POST", "http://localhost:8080/batch", bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not create a new request : %s", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not do the request : %s", err.Error())
+	}
+
+	var results []struct {
+		Type   string
+		Status int
+		Body   json.RawMessage
+	}
+	if err := json.NewDecoder(res.Body).Decode(&results); err != nil {
+		t.Fatalf(DefaultColors.Red+"Failed to unmarshal response: %v", err)
+	}
+	if len(results) != len(calls) {
+		t.Fatalf(DefaultColors.Red+"Expected %d results, got %d", len(calls), len(results))
+	}
+
+	for i, expected := range []string{"first", "second", "third"} {
+		var output procedure_test_output
+		if err := json.Unmarshal(results[i].Body, &output); err != nil {
+			t.Fatalf(DefaultColors.Red+"Failed to unmarshal result %d: %v", i, err)
+		}
+		if results[i].Status != 200 || output.FieldOneOut != expected {
+			t.Fatalf(DefaultColors.Red+"Unexpected result %d : %s", i, string(results[i].Body))
+		}
+	}
+	if results[3].Status != 400 || results[3].Type != "error" {
+		t.Fatalf(DefaultColors.Red+"The invalid call did not fail on its own, got status %d", results[3].Status)
+	}
+
+	postBatch := func(calls []procedureCall) *http.Response {
+		jsonData, _ := json.Marshal(calls)
+		req, _ := http.NewRequest("POST", "http://localhost:8080/batch", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatalf(DefaultColors.Red+"Could not do the request : %s", err.Error())
+		}
+		return res
+	}
+	if res := postBatch(append(calls, procedureCall{Type: QUERY, Path: "/query?query=fifth"})); res.StatusCode != 400 {
+		t.Fatalf(DefaultColors.Red+"Expected a batch above MaxBatchSize to be rejected, got %d", res.StatusCode)
+	}
+	res = postBatch([]procedureCall{{Type: MUTATION, Path: "/batch", Input: json.RawMessage(`[]`)}, {Type: QUERY, Path: "/query?query=first"}})
+	results = nil
+	if err := json.NewDecoder(res.Body).Decode(&results); err != nil || len(results) != 2 {
+		t.Fatalf(DefaultColors.Red+"Failed to unmarshal response: %v", err)
+	}
+	if results[0].Status != 400 || results[1].Status != 200 {
+		t.Fatalf(DefaultColors.Red+"Expected the nested batch to be rejected on its own, got %+v", results)
+	}
+
+	fmt.Println(DefaultColors.Green + "PASSED BATCHED CALLS" + DefaultColors.Reset)
+}
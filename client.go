@@ -0,0 +1,406 @@
+package bluerpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Client calls the procedures of a bluerpc server from another go service, with the same query, input and output structs as the server.
+// Values are encoded the same way the generated typescript encodes them (paramName and json tags, dynamic slugs...)
+type Client struct {
+	// The address of the server, for example http://localhost:8080
+	BaseURL string
+
+	// The http client that sends every call. Default is http.DefaultClient
+	HTTPClient *http.Client
+
+	// Headers sent with every call, for example an Authorization header. Use WithCallHeaders to add headers to a single call
+	Headers http.Header
+
+	// Must be the same as the Int64Mode of the server
+	Int64Mode Int64Mode
+}
+
+// Creates a new client for the server at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Headers: http.Header{},
+	}
+}
+
+type callHeadersKey struct{}
+
+// Returns a context that adds the headers to every call made with it. They override the headers of the client
+func WithCallHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, callHeadersKey{}, headers)
+}
+
+// Calls the query at path. The dynamic slugs of the path ({id} for example) are filled with the fields of the query that share their name
+func CallQuery[query any, output any](ctx context.Context, client *Client, path string, q query) (*Res[output], error) {
+	req, err := client.newRequest(ctx, http.MethodGet, path, q, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doCall[output](client, req)
+}
+
+// Calls the mutation at path with the input as its json body. The dynamic slugs of the path are filled with the fields of the query that share their name
+func CallMutation[query any, input any, output any](ctx context.Context, client *Client, path string, q query, in input) (*Res[output], error) {
+	return CallMutationWithMethod[query, input, output](ctx, client, http.MethodPost, path, q, in)
+}
+
+// Calls the mutation at path on the http method that it declared with HTTPMethod (PUT, PATCH or DELETE)
+func CallMutationWithMethod[query any, input any, output any](ctx context.Context, client *Client, httpMethod string, path string, q query, in input) (*Res[output], error) {
+	req, err := client.newRequest(ctx, httpMethod, path, q, in)
+	if err != nil {
+		return nil, err
+	}
+	return doCall[output](client, req)
+}
+
+// Subscribes to the subscription at path. Read its events with Next and Close it once you are done, cancelling ctx closes it as well
+func CallSubscription[query any, output any](ctx context.Context, client *Client, path string, q query) (*ClientSubscription[output], error) {
+	req, err := client.newRequest(ctx, http.MethodGet, path, q, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", TextEventStream)
+	resp, err := client.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, callError(resp.StatusCode, body)
+	}
+	return &ClientSubscription[output]{
+		body:   resp.Body,
+		reader: bufio.NewReader(resp.Body),
+		codec:  client.codec(),
+	}, nil
+}
+
+// ClientSubscription reads the events sent by a subscription
+type ClientSubscription[T any] struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	codec  *Ctx
+}
+
+// Waits for the next event. It returns io.EOF once the server ends the subscription and an *Error if the handler of the subscription failed
+func (s *ClientSubscription[T]) Next() (T, error) {
+	var event T
+	name := "message"
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			return event, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			if eventName, ok := strings.CutPrefix(line, "event:"); ok {
+				name = strings.TrimSpace(eventName)
+			} else if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(value, " "))
+			}
+			continue
+		}
+		if len(data) == 0 {
+			name = "message"
+			continue
+		}
+		payload := []byte(strings.Join(data, "\n"))
+		if name == "error" {
+			return event, callError(http.StatusInternalServerError, payload)
+		}
+		err = s.codec.decodeBody(payload, reflect.ValueOf(&event).Elem())
+		return event, err
+	}
+}
+
+// Stops the subscription
+func (s *ClientSubscription[T]) Close() error {
+	return s.body.Close()
+}
+
+func (client *Client) httpClient() *http.Client {
+	if client.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return client.HTTPClient
+}
+
+// the context that values are encoded and decoded with, so that the client follows the same rules as the server
+func (client *Client) codec() *Ctx {
+	return &Ctx{app: &App{config: &Config{Int64Mode: client.Int64Mode}}}
+}
+
+func (client *Client) newRequest(ctx context.Context, httpMethod string, path string, query any, input any) (*http.Request, error) {
+	callPath, err := buildCallPath(path, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if !isInterpretedAsEmpty(input) {
+		encoded, err := client.codec().marshalJSON(input)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, client.BaseURL+callPath, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range client.Headers {
+		req.Header[key] = append([]string{}, values...)
+	}
+	if headers, ok := ctx.Value(callHeadersKey{}).(http.Header); ok {
+		for key, values := range headers {
+			req.Header[http.CanonicalHeaderKey(key)] = append([]string{}, values...)
+		}
+	}
+	if httpMethod != http.MethodGet {
+		req.Header.Set("Content-Type", ApplicationJSON)
+	}
+	return req, nil
+}
+
+func doCall[output any](client *Client, req *http.Request) (*Res[output], error) {
+	resp, err := client.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, callError(resp.StatusCode, body)
+	}
+
+	res := &Res[output]{
+		Header: Header{
+			Status:          resp.StatusCode,
+			CacheControl:    resp.Header.Get("Cache-Control"),
+			ContentEncoding: resp.Header.Get("Content-Encoding"),
+			ContentType:     resp.Header.Get("Content-Type"),
+			Expires:         resp.Header.Get("Expires"),
+			Cookies:         resp.Cookies(),
+		},
+	}
+	if len(body) == 0 {
+		return res, nil
+	}
+	if err := client.codec().decodeBody(body, reflect.ValueOf(&res.Body).Elem()); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// decodes a response body or an event into the target. Bodies that are not json (text/plain responses) can only be decoded into strings
+func (c *Ctx) decodeBody(body []byte, target reflect.Value) error {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		if target.Kind() == reflect.String {
+			target.SetString(string(body))
+			return nil
+		}
+		return err
+	}
+	return c.decodeValue(decoded, target)
+}
+
+// turns an error response into an *Error. The server sends errors as {"message": "...", "data": ...} (see DefaultErrorMiddleware).
+// The data is kept as a json.RawMessage, see ErrorData
+func callError(status int, body []byte) error {
+	var errorBody struct {
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	message := strings.TrimSpace(string(body))
+	var data any
+	if err := json.Unmarshal(body, &errorBody); err == nil {
+		if errorBody.Message != "" {
+			message = errorBody.Message
+		}
+		if len(errorBody.Data) > 0 && string(errorBody.Data) != "null" {
+			data = errorBody.Data
+		}
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &Error{
+		Code:    status,
+		Message: message,
+		Data:    data,
+	}
+}
+
+// Decodes the data of an *Error returned by a call into the type that the procedure declared with Throws, the same way the bodies of the responses are decoded.
+// It reports false if the error holds no data or if the data does not fit into T
+func ErrorData[T any](client *Client, err error) (T, bool) {
+	var data T
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return data, false
+	}
+	raw, ok := rpcErr.Data.(json.RawMessage)
+	if !ok {
+		return data, false
+	}
+	if err := client.codec().decodeBody(raw, reflect.ValueOf(&data).Elem()); err != nil {
+		return data, false
+	}
+	return data, true
+}
+
+// Fills the dynamic slugs of the path ({id} or {path...}) with the given values. Slugs that are not in the map are left as they are
+func FillSlugs(path string, slugs map[string]string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		value, ok := slugs[strings.TrimSuffix(name, "...")]
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(name, "...") {
+			// wildcards that span multiple segments keep their slashes
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+		} else {
+			segments[i] = url.PathEscape(value)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// builds the address of a call. The fields of the query fill the dynamic slugs that share their name and every other non zero field becomes a query parameter,
+// named the way queryParser reads them (the paramName tag or the field name)
+func buildCallPath(path string, query any) (string, error) {
+	dynamicSlugNames := findDynamicSlugs(path)
+	slugs := map[string]string{}
+	values := url.Values{}
+
+	queryValue := reflect.ValueOf(query)
+	for queryValue.Kind() == reflect.Ptr || queryValue.Kind() == reflect.Interface {
+		if queryValue.IsNil() {
+			break
+		}
+		queryValue = queryValue.Elem()
+	}
+	if queryValue.Kind() == reflect.Struct {
+		for i := 0; i < queryValue.NumField(); i++ {
+			field := queryValue.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			queryKey := field.Tag.Get("paramName")
+			if queryKey == "" {
+				queryKey = field.Name
+			}
+			fieldValue := queryValue.Field(i)
+			isSlug := sliceStrContains(dynamicSlugNames, queryKey)
+			if !isSlug && fieldValue.IsZero() {
+				continue
+			}
+			encoded, ok, err := encodeQueryField(fieldValue)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode field '%s': %v", field.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			if isSlug {
+				slugs[queryKey] = encoded
+			} else {
+				values.Set(queryKey, encoded)
+			}
+		}
+	}
+
+	callPath := FillSlugs(path, slugs)
+	for _, slugName := range dynamicSlugNames {
+		if strings.Contains(callPath, "{"+slugName+"}") || strings.Contains(callPath, "{"+slugName+"...}") {
+			return "", fmt.Errorf("no value was given for the %s slug of %s", slugName, path)
+		}
+	}
+	// {$} only anchors the end of the path
+	callPath = strings.ReplaceAll(callPath, "{$}", "")
+	if len(values) > 0 {
+		callPath += "?" + values.Encode()
+	}
+	return callPath, nil
+}
+
+// turns a query field into the string that setField parses back. Slices are joined with commas
+func encodeQueryField(v reflect.Value) (string, bool, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() && implements(v.Type(), textMarshalerType) {
+		v = v.Addr()
+	}
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err == nil, err
+	}
+	v = reflect.Indirect(v)
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true, nil
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			part, ok, err := encodeQueryField(v.Index(i))
+			if err != nil {
+				return "", false, err
+			}
+			if ok {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, ","), true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported query type %s", v.Type())
+	}
+}
@@ -0,0 +1,305 @@
+package bluerpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type client_test_query struct {
+	Id   int64    `paramName:"id"`
+	Tags []string `paramName:"tags"`
+}
+type client_test_member struct {
+	Name  string `paramName:"name"`
+	Score int64  `json:"score"`
+}
+type client_test_team struct {
+	Id      int64                `paramName:"id"`
+	Tags    []string             `paramName:"tags"`
+	Members []client_test_member `paramName:"members"`
+	Owner   *client_test_member  `paramName:"owner"`
+}
+
+func TestGoClient(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING GO CLIENT" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		Int64Mode:           Int64AsString,
+		Authorizer: NewAuth(func(ctx *Ctx) (any, error) {
+			if ctx.Get("Authorization") != "Bearer test_token" {
+				return nil, &Error{Code: http.StatusUnauthorized, Message: "Unauthorized"}
+			}
+			return nil, nil
+		}),
+	})
+
+	teams := app.Router("/teams")
+	NewQuery(app, func(ctx *Ctx, query client_test_query) (*Res[client_test_team], error) {
+		return &Res[client_test_team]{
+			Body: client_test_team{
+				Id:      query.Id,
+				Tags:    query.Tags,
+				Members: []client_test_member{{Name: "ada", Score: 1 << 60}},
+			},
+		}, nil
+	}).Attach(teams, "/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input client_test_member) (*Res[client_test_member], error) {
+		return &Res[client_test_member]{Body: input}, nil
+	}).Protected().Attach(teams, "/members")
+	NewSubscription(app, func(ctx *Ctx, query any, emitter *Emitter[client_test_member]) error {
+		for i := 0; i < 2; i++ {
+			if err := emitter.Send(client_test_member{Score: int64(i)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Attach(teams, "/events")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, &Error{Code: http.StatusNotFound, Message: "team not found", Data: client_test_member{Name: "ada", Score: 1 << 60}}
+	}).Throws(http.StatusNotFound, client_test_member{}).Attach(teams, "/missing")
+
+	go app.Listen(":3002")
+	defer app.Shutdown()
+	if err := waitForServerReady(":3002"); err != nil {
+		t.Fatalf(DefaultColors.Red+"Server did not start : %s", err.Error())
+	}
+
+	client := NewClient("http://localhost:3002")
+	client.Int64Mode = Int64AsString
+	ctx := context.Background()
+
+	team, err := CallQuery[client_test_query, client_test_team](ctx, client, "/teams/{id}", client_test_query{Id: 42, Tags: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not call the query : %s", err.Error())
+	}
+	if team.Header.Status != http.StatusOK || team.Body.Id != 42 || strings.Join(team.Body.Tags, ",") != "a,b" {
+		t.Fatalf(DefaultColors.Red+"Expected the query and the slug to reach the server, got %+v", team.Body)
+	}
+	if len(team.Body.Members) != 1 || team.Body.Members[0].Name != "ada" || team.Body.Members[0].Score != 1<<60 || team.Body.Owner != nil {
+		t.Fatalf(DefaultColors.Red+"Expected the members to be decoded by their wire names, got %+v", team.Body.Members)
+	}
+
+	_, err = CallMutation[any, client_test_member, client_test_member](ctx, client, "/teams/members", nil, client_test_member{Name: "grace"})
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != http.StatusUnauthorized || rpcErr.Message != "Unauthorized" {
+		t.Fatalf(DefaultColors.Red+"Expected a 401 error, got %v", err)
+	}
+
+	_, err = CallQuery[any, any](ctx, client, "/teams/missing", nil)
+	data, ok := ErrorData[client_test_member](client, err)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != http.StatusNotFound || !ok || data.Name != "ada" || data.Score != 1<<60 {
+		t.Fatalf(DefaultColors.Red+"Expected a 404 error with its data, got %v", err)
+	}
+
+	authCtx := WithCallHeaders(ctx, http.Header{"Authorization": {"Bearer test_token"}})
+	member, err := CallMutation[any, client_test_member, client_test_member](authCtx, client, "/teams/members", nil, client_test_member{Name: "grace", Score: 7})
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not call the mutation : %s", err.Error())
+	}
+	if member.Body.Name != "grace" || member.Body.Score != 7 {
+		t.Fatalf(DefaultColors.Red+"Expected the input to be sent back, got %+v", member.Body)
+	}
+
+	events, err := CallSubscription[any, client_test_member](ctx, client, "/teams/events", nil)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not subscribe : %s", err.Error())
+	}
+	defer events.Close()
+	for i := 0; i < 2; i++ {
+		event, err := events.Next()
+		if err != nil || event.Score != int64(i) {
+			t.Fatalf(DefaultColors.Red+"Expected the event %d, got %+v (%v)", i, event, err)
+		}
+	}
+	if _, err := events.Next(); err != io.EOF {
+		t.Fatalf(DefaultColors.Red+"Expected the subscription to end, got %v", err)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED GO CLIENT" + DefaultColors.Reset)
+}
+
+func TestGoClientOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING GO CLIENT OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		Int64Mode:           Int64AsString,
+	})
+	teams := app.Router("/teams")
+	NewQuery(app, func(ctx *Ctx, query client_test_query) (*Res[client_test_team], error) {
+		return nil, nil
+	}).Attach(teams, "/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input User) (*Res[any], error) {
+		return nil, nil
+	}).Attach(teams, "/{team}/members")
+
+	source, err := app.GenerateGoClient("teams")
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the go client : %s", err.Error())
+	}
+	for _, expected := range []string{
+		"// Code generated by bluerpc. DO NOT EDIT.",
+		"package teams",
+		"rpc.Int64Mode = bluerpc.Int64AsString",
+		"Teams *TeamsClient",
+		"func (c *TeamsClient) ById(ctx context.Context, query ClientTestQuery) (*bluerpc.Res[ClientTestTeam], error) {",
+		`return bluerpc.CallQuery[ClientTestQuery, ClientTestTeam](ctx, c.rpc, "/teams/{id}", query)`,
+		"func (c *TeamsClient) ByTeamMembers(ctx context.Context, input bluerpc.User, teamSlug string) (*bluerpc.Res[any], error) {",
+		`bluerpc.CallMutation[any, bluerpc.User, any](ctx, c.rpc, bluerpc.FillSlugs("/teams/{team}/members", map[string]string{"team": teamSlug}), nil, input)`,
+		"type ClientTestTeam struct {",
+		"Members []ClientTestMember `paramName:\"members\"`",
+		"Owner   *ClientTestMember  `paramName:\"owner\"`",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf(DefaultColors.Red+"Expected the go client to contain %s, got\n%s", expected, source)
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED GO CLIENT OUTPUT" + DefaultColors.Reset)
+}
+
+func TestPythonClientOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING PYTHON CLIENT OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		Int64Mode:           Int64AsString,
+	})
+	teams := app.Router("/teams")
+	NewQuery(app, func(ctx *Ctx, query client_test_query) (*Res[client_test_team], error) {
+		return nil, nil
+	}).Attach(teams, "/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input client_test_member) (*Res[any], error) {
+		return nil, nil
+	}).Throws(http.StatusNotFound, client_test_member{}).Deprecated("v2").Attach(teams, "/{team}/members")
+	NewSubscription(app, func(ctx *Ctx, query any, emitter *Emitter[client_test_member]) error {
+		return nil
+	}).Attach(teams, "/events")
+
+	source, err := app.GeneratePythonClient()
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the python client : %s", err.Error())
+	}
+	for _, expected := range []string{
+		"# Code generated by bluerpc. DO NOT EDIT.",
+		`Int64 = Annotated[int, PlainSerializer(str, return_type=str, when_used="json")]`,
+		"class ClientTestTeam(BaseModel):",
+		`    members: list[ClientTestMember] = Field(alias="members")`,
+		`    owner: Optional[ClientTestMember] = Field(alias="owner")`,
+		"        self.teams = TeamsClient(rpc)",
+		"    def by_id(self, query: ClientTestQuery, *, headers: Optional[Mapping[str, str]] = None) -> Response[ClientTestTeam]:",
+		`        return self._rpc.call("GET", "/teams/{id}", ClientTestTeam, query=query, headers=headers)`,
+		"    def by_team_members(self, input: ClientTestMember, team_slug: str, *, headers: Optional[Mapping[str, str]] = None) -> Response[Any]:",
+		`slugs={"team": team_slug}, errors={404: ClientTestMember}, headers=headers)`,
+		`        warnings.warn("/teams/{team}/members is deprecated since v2", DeprecationWarning, stacklevel=2)`,
+		`        return self._rpc.subscribe("/teams/events", ClientTestMember, headers=headers)`,
+		"ClientTestTeam.model_rebuild()",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf(DefaultColors.Red+"Expected the python client to contain %s, got\n%s", expected, source)
+		}
+	}
+
+	// the syntax is checked when python is installed
+	if python, err := exec.LookPath("python3"); err == nil {
+		path := filepath.Join(t.TempDir(), "client.py")
+		if err := os.WriteFile(path, source, 0644); err != nil {
+			t.Fatalf(DefaultColors.Red+"Could not write the python client : %s", err.Error())
+		}
+		if output, err := exec.Command(python, "-m", "py_compile", path).CombinedOutput(); err != nil {
+			t.Fatalf(DefaultColors.Red+"The python client is invalid : %s", output)
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED PYTHON CLIENT OUTPUT" + DefaultColors.Reset)
+}
+
+// the app of the swift and kotlin client tests
+func newMobileClientTestApp() *App {
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		Int64Mode:           Int64AsString,
+	})
+	teams := app.Router("/teams")
+	NewQuery(app, func(ctx *Ctx, query client_test_query) (*Res[client_test_team], error) {
+		return nil, nil
+	}).Attach(teams, "/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input client_test_member) (*Res[any], error) {
+		return nil, nil
+	}).Throws(http.StatusNotFound, client_test_member{}).Deprecated("v2").Attach(teams, "/{team}/members")
+	NewSubscription(app, func(ctx *Ctx, query any, emitter *Emitter[client_test_member]) error {
+		return nil
+	}).Attach(teams, "/events")
+	return app
+}
+
+func TestSwiftClientOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING SWIFT CLIENT OUTPUT" + DefaultColors.Reset)
+	source, err := newMobileClientTestApp().GenerateSwiftClient()
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the swift client : %s", err.Error())
+	}
+	for _, expected := range []string{
+		"// Code generated by bluerpc. DO NOT EDIT.",
+		"public struct RpcInt64: Codable",
+		"public struct ClientTestTeam: Codable {",
+		"    public var members: [ClientTestMember]\n",
+		"    public var owner: ClientTestMember?\n",
+		"        case members = \"members\"\n",
+		"    public init(id: RpcInt64, tags: [String]? = nil) {",
+		"    public var teams: TeamsClient { TeamsClient(rpc: rpc) }",
+		"    public func byId(_ query: ClientTestQuery, headers: [String: String] = [:]) async throws -> RpcResponse<ClientTestTeam> {\n" +
+			"        try await rpc.call(\"GET\", \"/teams/{id}\", query: query, headers: headers)",
+		"    /// - Throws: RpcError, its data is a ClientTestMember when its status is 404\n" +
+			"    @available(*, deprecated, message: \"/teams/{team}/members is deprecated since v2\")\n" +
+			"    public func byTeamMembers(_ input: ClientTestMember, teamSlug: String, headers: [String: String] = [:]) async throws -> RpcResponse<JSONValue> {",
+		`slugs: ["team": teamSlug], headers: headers)`,
+		"    public func events(headers: [String: String] = [:]) -> AsyncThrowingStream<ClientTestMember, Error> {",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf(DefaultColors.Red+"Expected the swift client to contain %s, got\n%s", expected, source)
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED SWIFT CLIENT OUTPUT" + DefaultColors.Reset)
+}
+
+func TestKotlinClientOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING KOTLIN CLIENT OUTPUT" + DefaultColors.Reset)
+	app := newMobileClientTestApp()
+	source, err := app.kotlinClientFile("./android/api/Client.kt")
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the kotlin client : %s", err.Error())
+	}
+	for _, expected := range []string{
+		"// Code generated by bluerpc. DO NOT EDIT.",
+		"package api\n",
+		"typealias RpcInt64 = @Serializable(with = Int64AsStringSerializer::class) Long",
+		"@Serializable\ndata class ClientTestTeam(\n",
+		`    @SerialName("members") val members: List<ClientTestMember>,`,
+		`    @SerialName("owner") val owner: ClientTestMember?,`,
+		`    @SerialName("id") val id: RpcInt64,`,
+		"    val teams = TeamsClient(rpc)",
+		"    suspend fun byId(query: ClientTestQuery, headers: Map<String, String> = emptyMap()): RpcResponse<ClientTestTeam> =\n" +
+			`        rpc.call(HttpMethod.Get, "/teams/{id}", ClientTestTeam.serializer(), query = rpc.encodeQuery(ClientTestQuery.serializer(), query), headers = headers)`,
+		"     * @throws RpcException its data is a ClientTestMember when its status is 404\n     */\n" +
+			`    @Deprecated("/teams/{team}/members is deprecated since v2")`,
+		`input = rpc.json.encodeToString(ClientTestMember.serializer(), input), slugs = mapOf("team" to teamSlug), headers = headers)`,
+		"    fun events(headers: Map<String, String> = emptyMap()): Flow<ClientTestMember> =",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf(DefaultColors.Red+"Expected the kotlin client to contain %s, got\n%s", expected, source)
+		}
+	}
+
+	if _, err := app.GenerateKotlinClient("com.example-api"); err == nil {
+		t.Fatalf(DefaultColors.Red + "Expected an invalid package name to be refused")
+	}
+	fmt.Println(DefaultColors.Green + "PASSED KOTLIN CLIENT OUTPUT" + DefaultColors.Reset)
+}
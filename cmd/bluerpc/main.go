@@ -0,0 +1,120 @@
+// Command bluerpc generates the clients of a bluerpc app without serving it and compares the schemas of its versions :
+//
+//	bluerpc gen [--check] [package]
+//	bluerpc diff previous.json current.json
+//
+// gen runs the package (the current directory by default) with BLUERPC_GEN set. The program must call App.RunGen before App.Listen and exit when it returns true,
+// RunGen then writes every client of the app instead of serving it.
+// With --check the clients are only compared with the files on disk and the command fails if one of them is out of date, which is meant to be run in CI.
+//
+// diff compares two schemas written with Config.SchemaOutputPath, the committed one and the one of the current build for example,
+// and fails if the changes break the clients that were generated from the previous one
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/blue-rpc/bluerpc"
+)
+
+const usage = `usage: bluerpc gen [--check] [package]
+       bluerpc diff previous.json current.json
+
+gen generates the clients of the bluerpc app in package (default ".") without serving it.
+The app must call app.RunGen() before Listen and exit when it returns true.
+
+diff compares two schemas written with SchemaOutputPath and fails if the changes break
+the clients generated from the previous one.
+
+flags:
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "gen":
+		gen(os.Args[2:])
+	case "diff":
+		diff(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func gen(args []string) {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	check := flags.Bool("check", false, "fail when a generated client differs from the file on disk instead of writing it")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	pkg := "."
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if flags.NArg() == 1 {
+		pkg = flags.Arg(0)
+	}
+
+	mode := "write"
+	if *check {
+		mode = "check"
+	}
+	os.Exit(run(pkg, mode))
+}
+
+func diff(args []string) {
+	if len(args) != 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	previous, err := bluerpc.ReadSchemaFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	current, err := bluerpc.ReadSchemaFile(args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	changes := bluerpc.CompareSchemas(previous, current)
+	if len(changes) == 0 {
+		fmt.Println(bluerpc.DefaultColors.Green + "no breaking changes" + bluerpc.DefaultColors.Reset)
+		return
+	}
+	fmt.Fprintf(os.Stderr, bluerpc.DefaultColors.Red+"%d breaking changes :\n"+bluerpc.DefaultColors.Reset, len(changes))
+	for _, change := range changes {
+		fmt.Fprintln(os.Stderr, "  "+change.String())
+	}
+	os.Exit(1)
+}
+
+// runs the package with the generation mode and returns its exit code
+func run(pkg string, mode string) int {
+	cmd := exec.Command("go", "run", pkg)
+	cmd.Env = append(os.Environ(), bluerpc.GenerateEnv+"="+mode)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
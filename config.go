@@ -29,7 +29,7 @@ type Config struct {
 	//By default it is DefaultErrorMiddleware
 	ErrorMiddleware Handler
 
-	//the URL of the server. If left empty it will be interpreted as localhost
+	//the URL of the server with its port, http://localhost:8080 for example. The generated clients and the OpenAPI document call it as it is. If left empty it will be interpreted as localhost
 	ServerURL string
 
 	//disable the printing of that start server message
@@ -44,6 +44,79 @@ type Config struct {
 	//The address that your SSL key is located at
 	SSLKey string
 
+	// The path at which the websocket endpoint is served, for example /ws.
+	// Every query, mutation and subscription can be called through that single connection and the generated typescript gets a websocket link.
+	// Websockets are disabled when this is left empty
+	WebSocketPath string
+
+	// The path of the batch endpoint, for example /batch.
+	// Clients can POST an array of calls to it and get back an array with the result of every call. The generated typescript then groups every call made in the same tick into a single request.
+	// Batching is disabled when this is left empty
+	BatchPath string
+
+	// The maximum number of calls in a single batch, larger batches are answered with a 400. The generated typescript splits its batches accordingly. Default is 50
+	MaxBatchSize int
+
+	// Generates a zod schema next to every typescript type, along with the query, input and output schemas of every procedure in rpcSchemas.
+	// The common validate rules (required, min, max, len, email, oneof...) are translated into the schemas. Your frontend then needs zod installed.
+	// Response bodies can be validated against their schema at runtime by calling setResponseValidation(true), which is meant for development
+	GenerateZod bool
+
+	// Generates rpcQuery next to rpcAPI, with a TanStack Query options factory for every query (useQuery) and mutation (useMutation)
+	// and the query keys of every procedure and router so that they can be invalidated. Default is false
+	GenerateTanStackQuery bool
+
+	// Splits the typescript client into one module per top level router, written next to OutputPath : runtime.ts, types.ts for the shared types and schemas,
+	// a module per router with the schemas of its procedures (users.ts for /users, root.ts for the procedures attached to the app) and OutputPath itself as the index that combines them into rpcAPI and rpcSchemas.
+	// Frontend bundles then only include the routers they import. Point OutputPath to a file of its own directory, for example ./client/index.ts. Default is false
+	SplitTSOutput bool
+
+	// Determines how int64 and uint64 values are sent and typed in the generated typescript. Javascript numbers lose precision above 2^53.
+	// Int64AsString sends them as json strings typed as string and Int64AsBigInt sends them as json strings that the generated client turns into bigints.
+	// Default is Int64AsNumber
+	Int64Mode Int64Mode
+
+	// Rejects the queries and inputs that hold a value that is not part of its enum (see RegisterEnum and the enum tag) with a 400 error.
+	// Default is false
+	ValidateEnums bool
+
+	// The path at which the OpenAPI 3.1 document of the app is served, for example /openapi.json.
+	// The document is not served when this is left empty
+	OpenAPIPath string
+
+	// The file that the OpenAPI document is written to when the app starts listening, for example ./openapi.json.
+	// The document is not written when this is left empty
+	OpenAPIOutputPath string
+
+	// The title, version and description of the OpenAPI document
+	OpenAPIInfo OpenAPIInfo
+
+	// The file that the typed go client of the app is written to when the app starts listening, for example ./client/client.go.
+	// Other go services can then call every procedure with the same structs as the server. The client is not written when this is left empty
+	GoClientOutputPath string
+
+	// The package name of the generated go client. Default is the name of the directory of GoClientOutputPath
+	GoClientPackage string
+
+	// The file that the typed python client of the app is written to when the app starts listening, for example ./client/bluerpc_client.py.
+	// It uses httpx and pydantic models. The client is not written when this is left empty
+	PythonClientOutputPath string
+
+	// The file that the swift client of the app is written to when the app starts listening, for example ./ios/BlueRPCClient.swift.
+	// It declares Codable structs and only needs Foundation. The client is not written when this is left empty
+	SwiftClientOutputPath string
+
+	// The file that the kotlin client of the app is written to when the app starts listening, for example ./android/client/Client.kt.
+	// It uses ktor and kotlinx.serialization data classes. The client is not written when this is left empty
+	KotlinClientOutputPath string
+
+	// The package of the generated kotlin client, for example com.example.api. Default is the name of the directory of KotlinClientOutputPath
+	KotlinClientPackage string
+
+	// The file that the schema of the app (see App.Schema) is written to along with the clients, for example ./schema.json.
+	// Commit it and compare it with the next one with bluerpc diff in order to catch the changes that break the clients that are already shipped
+	SchemaOutputPath string
+
 	// Puts all of the needed Pprof routes in. Read more about pprof here
 	// https://pkg.go.dev/net/http/pprof
 	EnablePProf bool
@@ -52,6 +125,11 @@ type Config struct {
 // Struct that handles all of the settings related to authorizing for routes and procedures
 type Authorizer struct {
 	Handler AuthHandler
+
+	// The name and the scheme under which this authorizer is documented in the OpenAPI document.
+	// Default is an http bearer scheme named bearerAuth
+	SecuritySchemeName string
+	SecurityScheme     *SecurityScheme
 }
 
 // Creates a new authorizer struct with the defaults set
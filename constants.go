@@ -12,6 +12,7 @@ const (
 	ApplicationForm       = "application/x-www-form-urlencoded"
 	OctetStream           = "application/octet-stream"
 	MultipartForm         = "multipart/form-data"
+	TextEventStream       = "text/event-stream"
 
 	TextXMLCharsetUTF8               = "text/xml; charset=utf-8"
 	TextHTMLCharsetUTF8              = "text/html; charset=utf-8"
@@ -17,6 +17,7 @@ type Ctx struct {
 	httpW       http.ResponseWriter
 	nextHandler Handler
 	auth        any
+	app         *App
 
 	// This session field can be used in your middlewares for you to store any data that you would need to pass on to your handlers
 	Session any
@@ -241,39 +242,111 @@ func (c *Ctx) decodeJSON(target interface{}) error {
 		return err
 	}
 
-	// Get the reflect value and type of the target struct
-	val := reflect.ValueOf(target).Elem()
-	typ := val.Type()
-
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
+	return c.decodeFields(dataMap, reflect.ValueOf(target).Elem())
+}
 
-		// Use paramName tag as priority, fallback to field name
-		paramName := fieldType.Tag.Get("paramName")
-		if paramName == "" {
-			paramName = strings.ToLower(fieldType.Name)
+// sets the fields of the struct from the decoded json object. Nested objects are decoded the same way so that their fields follow the same names as the generated typescript
+func (c *Ctx) decodeFields(dataMap map[string]interface{}, val reflect.Value) error {
+	// the keys are matched the same way encoding/json does, exactly first and then case insensitively
+	for _, wf := range wireFields(val.Type()) {
+		jsonValue, exists := dataMap[wf.name]
+		if !exists {
+			for key, value := range dataMap {
+				if strings.EqualFold(key, wf.name) {
+					jsonValue, exists = value, true
+					break
+				}
+			}
 		}
-
-		// Find the corresponding JSON value
-		jsonValue, exists := dataMap[paramName]
 		if !exists {
 			continue
 		}
 
 		// Ensure the field can be set
-		if !field.CanSet() {
+		field := fieldByIndexAlloc(val, wf.index)
+		if !field.IsValid() || !field.CanSet() {
 			continue
 		}
 
-		// Convert and set the field value
-		if err := setFieldValue(field, jsonValue); err != nil {
-			return fmt.Errorf("failed to set field '%s': %v", fieldType.Name, err)
+		// fields with the ",string" option are sent as json encoded strings
+		if str, ok := jsonValue.(string); ok && wf.asString {
+			if err := json.Unmarshal([]byte(str), field.Addr().Interface()); err != nil {
+				return fmt.Errorf("failed to set field '%s': %v", wf.field.Name, err)
+			}
+			continue
+		}
+
+		if err := c.decodeValue(jsonValue, field); err != nil {
+			return fmt.Errorf("failed to set field '%s': %v", wf.field.Name, err)
 		}
 	}
 
 	return nil
 }
+
+// sets a decoded json value on the target. Structs are decoded field by field (see decodeFields) and so are the structs inside of slices and maps,
+// int64 values can arrive as json encoded strings (depending on the app's Int64Mode) and everything else is converted by setFieldValue
+func (c *Ctx) decodeValue(value interface{}, target reflect.Value) error {
+	if value == nil {
+		return nil
+	}
+	if str, ok := value.(string); ok && c.int64Mode().sendsString(target.Kind()) {
+		return json.Unmarshal([]byte(str), target.Addr().Interface())
+	}
+
+	targetType := target.Type()
+	if targetType.Kind() == reflect.Ptr && !implements(targetType, jsonUnmarshalerType) && !implements(targetType, textUnmarshalerType) {
+		if target.IsNil() {
+			target.Set(reflect.New(targetType.Elem()))
+		}
+		return c.decodeValue(value, target.Elem())
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if isDecodedFieldByField(targetType) {
+			return c.decodeFields(v, target)
+		}
+		if targetType.Kind() == reflect.Map && targetType.Key().Kind() == reflect.String && !implements(targetType, jsonUnmarshalerType) {
+			decodedMap := reflect.MakeMapWithSize(targetType, len(v))
+			for key, elemValue := range v {
+				elem := reflect.New(targetType.Elem()).Elem()
+				if err := c.decodeValue(elemValue, elem); err != nil {
+					return err
+				}
+				decodedMap.SetMapIndex(reflect.ValueOf(key).Convert(targetType.Key()), elem)
+			}
+			target.Set(decodedMap)
+			return nil
+		}
+	case []interface{}:
+		if targetType.Kind() == reflect.Slice && !implements(targetType, jsonUnmarshalerType) {
+			decodedSlice := reflect.MakeSlice(targetType, len(v), len(v))
+			for i, elemValue := range v {
+				if err := c.decodeValue(elemValue, decodedSlice.Index(i)); err != nil {
+					return err
+				}
+			}
+			target.Set(decodedSlice)
+			return nil
+		}
+	}
+
+	// Convert and set the field value
+	return setFieldValue(target, value)
+}
+
+// reports if the type is a struct that is decoded field by field rather than by its own json unmarshaler
+func isDecodedFieldByField(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	return !implements(t, jsonUnmarshalerType) && !implements(t, textUnmarshalerType)
+}
+
 func setFieldValue(field reflect.Value, value interface{}) error {
 	if value == nil {
 		return nil
@@ -286,12 +359,20 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 	if valueType.AssignableTo(fieldType) {
 		field.Set(reflect.ValueOf(value))
 		return nil
-	} else if valueType.ConvertibleTo(fieldType) {
+	} else if valueType.Kind() != reflect.String && valueType.ConvertibleTo(fieldType) {
 		field.Set(reflect.ValueOf(value).Convert(fieldType))
 		return nil
 	}
 
-	return fmt.Errorf("type mismatch: cannot assign %v to %v", valueType, fieldType)
+	// everything else (time.Time, byte slices, nested structs, named strings...) is decoded the same way encoding/json would
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(encoded, field.Addr().Interface()); err != nil {
+		return fmt.Errorf("type mismatch: cannot assign %v to %v", valueType, fieldType)
+	}
+	return nil
 }
 func (c *Ctx) decodeForm(targetStruct interface{}) error {
 	if err := c.httpR.ParseForm(); err != nil {
@@ -327,87 +408,97 @@ func (c *Ctx) jSON(data interface{}) error {
 }
 
 func (c *Ctx) marshalJSON(data interface{}) ([]byte, error) {
-	v := reflect.ValueOf(data)
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+	value, err := c.toJSONValue(reflect.ValueOf(data))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// converts a value into what is put on the wire. Structs become maps keyed by their json names (see wireFields), nil pointer fields are left out
+// and the values that encode themselves (time.Time, custom marshalers...) are encoded by encoding/json
+func (c *Ctx) toJSONValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if encoded, ok, err := marshalSelf(v); ok {
+		return encoded, err
 	}
 
 	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return c.toJSONValue(v.Elem())
 	case reflect.Struct:
 		result := make(map[string]interface{})
-		t := v.Type()
-		for i := 0; i < v.NumField(); i++ {
-			field := t.Field(i)
-			key, fieldValue := c.getFieldKeyAndValue(field, v.Field(i))
-			if !fieldValue.IsValid() || !field.IsExported() {
+		for _, wf := range wireFields(v.Type()) {
+			fieldValue, ok := fieldByIndex(v, wf.index)
+			if !ok || (wf.omitEmpty && isEmptyValue(fieldValue)) {
 				continue
 			}
-			switch fieldValue.Kind() {
-			case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
-				if fieldValue.Kind() == reflect.Map && fieldValue.Type().Key().Kind() != reflect.String {
-					result[key] = fieldValue.Interface()
-					continue
-				}
-				// Recursively process nested structs, slices, arrays, and string-keyed maps
-				subResult, err := c.marshalJSON(fieldValue.Interface())
+			if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+				continue
+			}
+			if wf.asString {
+				encoded, err := json.Marshal(reflect.Indirect(fieldValue).Interface())
 				if err != nil {
 					return nil, err
 				}
-				// For slices and arrays, unmarshal the result to maintain type
-				if fieldValue.Kind() == reflect.Slice || fieldValue.Kind() == reflect.Array {
-					var sliceResult []interface{}
-					if err := json.Unmarshal(subResult, &sliceResult); err != nil {
-						return nil, err
-					}
-					result[key] = sliceResult
-				} else {
-					var mapOrStructResult interface{}
-					if err := json.Unmarshal(subResult, &mapOrStructResult); err != nil {
-						return nil, err
-					}
-					result[key] = mapOrStructResult
-				}
-			default:
-				result[key] = fieldValue.Interface()
+				result[wf.name] = string(encoded)
+				continue
+			}
+			fieldResult, err := c.toJSONValue(fieldValue)
+			if err != nil {
+				return nil, err
 			}
+			result[wf.name] = fieldResult
 		}
-		return json.Marshal(result)
+		return result, nil
 	case reflect.Slice, reflect.Array:
-		// Handle slices and arrays at the top level
 		sliceResult := make([]interface{}, v.Len())
 		for i := 0; i < v.Len(); i++ {
-			elem := v.Index(i)
-			processedElem, err := c.marshalJSON(elem.Interface())
+			elemResult, err := c.toJSONValue(v.Index(i))
 			if err != nil {
 				return nil, err
 			}
-			var intermediate interface{}
-			if err := json.Unmarshal(processedElem, &intermediate); err != nil {
+			sliceResult[i] = elemResult
+		}
+		return sliceResult, nil
+	case reflect.Map:
+		// maps that are not keyed by strings are left to encoding/json
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface(), nil
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		mapResult := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			valueResult, err := c.toJSONValue(iter.Value())
+			if err != nil {
 				return nil, err
 			}
-			sliceResult[i] = intermediate
+			mapResult[iter.Key().String()] = valueResult
 		}
-		return json.Marshal(sliceResult)
+		return mapResult, nil
 	default:
-		return json.Marshal(data)
+		if c.int64Mode().sendsString(v.Kind()) {
+			encoded, err := json.Marshal(v.Interface())
+			return string(encoded), err
+		}
+		return v.Interface(), nil
 	}
 }
 
-func (c *Ctx) getFieldKeyAndValue(field reflect.StructField, value reflect.Value) (string, reflect.Value) {
-	jsonTag := field.Tag.Get("json")
-	paramNameTag := field.Tag.Get("paramName")
-
-	key := field.Name
-	if jsonTag != "" {
-		key = jsonTag
-	} else if paramNameTag != "" {
-		key = paramNameTag
-	}
-	if value.Kind() == reflect.Ptr {
-		value = value.Elem()
+// returns the way int64 values are sent by the app that serves this request
+func (c *Ctx) int64Mode() Int64Mode {
+	if c.app == nil {
+		return Int64AsNumber
 	}
-	key = strings.Split(key, ",")[0] // Remove options like omitempty
-	return key, value
+	return c.app.config.Int64Mode
 }
 
 func (c *Ctx) status(code int) *Ctx {
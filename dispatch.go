@@ -0,0 +1,195 @@
+package bluerpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// a single procedure call that did not arrive as its own http request (a websocket frame or an entry of a batch).
+// It gets turned into a regular request and served by the app's mux so that it goes through the exact same middlewares, authorizers and validation as any other call
+type procedureCall struct {
+	Id   string `json:"id,omitempty"`
+	Type Method `json:"type,omitempty"`
+	// the http method of a mutation that is not served on POST (see HTTPMethod)
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path"`
+	Query   map[string]any    `json:"query,omitempty"`
+	Input   json.RawMessage   `json:"input,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type procedureCallResult struct {
+	Id      string            `json:"id,omitempty"`
+	Type    string            `json:"type,omitempty"`
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// these headers belong to the connection that carried the call and must not be copied onto the call itself
+var connectionHeaders = []string{"Connection", "Upgrade", "Content-Length", "Content-Type", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Sec-Websocket-Protocol"}
+
+// builds the http request that represents the call. Headers (cookies, authorization...) are inherited from the parent request and then overridden by the call's own headers
+func newCallRequest(ctx context.Context, parent *http.Request, call *procedureCall) (*http.Request, error) {
+	httpMethod := http.MethodGet
+	if call.Type == MUTATION {
+		httpMethod = http.MethodPost
+		switch method := strings.ToUpper(call.Method); method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			httpMethod = method
+		}
+	}
+
+	if call.Path == "" || call.Path[0] != '/' {
+		return nil, fmt.Errorf("invalid procedure path %q", call.Path)
+	}
+	callUrl, err := url.Parse(call.Path)
+	if err != nil {
+		return nil, err
+	}
+	query := callUrl.Query()
+	for key, value := range call.Query {
+		if encoded, ok := encodeQueryValue(value); ok {
+			query.Set(key, encoded)
+		}
+	}
+	callUrl.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, callUrl.String(), bytes.NewReader(call.Input))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = parent.Header.Clone()
+	for _, header := range connectionHeaders {
+		req.Header.Del(header)
+	}
+	if len(call.Input) != 0 {
+		req.Header.Set("Content-Type", ApplicationJSON)
+	}
+	for key, value := range call.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Host = parent.Host
+	req.RemoteAddr = parent.RemoteAddr
+	req.TLS = parent.TLS
+	return req, nil
+}
+
+// turns a decoded json query value into the string representation that queryParser expects. Arrays are joined with commas
+func encodeQueryValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, elem := range v {
+			if encoded, ok := encodeQueryValue(elem); ok {
+				parts = append(parts, encoded)
+			}
+		}
+		return strings.Join(parts, ","), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// callWriter is the response writer that calls are served into. It keeps the response in memory instead of sending it over the network.
+// Streaming responses (subscriptions) are handed to onFlush every time the handler flushes
+type callWriter struct {
+	header  http.Header
+	status  int
+	body    bytes.Buffer
+	onFlush func(w *callWriter)
+}
+
+func newCallWriter() *callWriter {
+	return &callWriter{header: http.Header{}}
+}
+
+func (w *callWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *callWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+func (w *callWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *callWriter) Flush() {
+	if w.onFlush != nil {
+		w.onFlush(w)
+	}
+}
+
+// returns the status of the response, defaulting to 200 like net/http does
+func (w *callWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// decodes a response body depending on its content type. JSON bodies are kept as raw json and everything else is sent back as a string
+func decodeCallBody(contentType string, body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if strings.Contains(contentType, ApplicationJSON) && json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return string(body)
+}
+
+// removes every complete server sent event from the buffer and returns them. Incomplete events stay in the buffer until the next flush
+func takeServerSentEvents(buffer *bytes.Buffer) []serverSentEvent {
+	var events []serverSentEvent
+	for {
+		content := buffer.Bytes()
+		boundary := bytes.Index(content, []byte("\n\n"))
+		if boundary == -1 {
+			return events
+		}
+		chunk := string(content[:boundary])
+		buffer.Next(boundary + 2)
+
+		event := serverSentEvent{name: "message"}
+		var data []string
+		for _, line := range strings.Split(chunk, "\n") {
+			if name, ok := strings.CutPrefix(line, "event:"); ok {
+				event.name = strings.TrimSpace(name)
+			} else if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(value, " "))
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+		event.data = []byte(strings.Join(data, "\n"))
+		events = append(events, event)
+	}
+}
+
+type serverSentEvent struct {
+	name string
+	data []byte
+}
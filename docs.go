@@ -0,0 +1,114 @@
+package bluerpc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// the metadata of a procedure, set with Summary, Describe, Tags and Deprecated. It is written as JSDoc in the typescript, in the OpenAPI document, in the go client and in PrintInfo
+type procedureDocs struct {
+	summary         string
+	description     string
+	tags            []string
+	deprecated      bool
+	deprecatedSince string
+}
+
+// the lines of the documentation of the procedure, empty if it has none
+func (docs procedureDocs) lines() []string {
+	lines := []string{}
+	if docs.summary != "" {
+		lines = append(lines, strings.Split(docs.summary, "\n")...)
+	}
+	if docs.description != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(docs.description, "\n")...)
+	}
+	if len(docs.tags) > 0 {
+		lines = append(lines, "@tags "+strings.Join(docs.tags, ", "))
+	}
+	if docs.deprecated {
+		lines = append(lines, strings.TrimSpace("@deprecated "+docs.deprecatedMessage()))
+	}
+	return lines
+}
+
+func (docs procedureDocs) deprecatedMessage() string {
+	if docs.deprecatedSince == "" {
+		return ""
+	}
+	return "since " + docs.deprecatedSince
+}
+
+// the JSDoc comment of the procedure, "" if it has no documentation
+func (docs procedureDocs) jsDoc() string {
+	return jsDoc(docs.lines()...)
+}
+
+// writes the lines as a JSDoc comment, on a single line when there is only one of them
+func jsDoc(lines ...string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	for i, line := range lines {
+		// a */ inside of the documentation would end the comment
+		lines[i] = strings.ReplaceAll(strings.TrimRight(line, " \t\r"), "*/", "*\\/")
+	}
+	if len(lines) == 1 {
+		return "/** " + lines[0] + " */"
+	}
+	comment := "/**\n"
+	for _, line := range lines {
+		comment += strings.TrimRight(" * "+line, " ") + "\n"
+	}
+	return comment + " */"
+}
+
+// the value of the Deprecation header (RFC 9745), the date of the deprecation as @epoch. RFC 9745 only allows a date,
+// so it returns false when the procedure was deprecated since a version or since nothing
+func (docs procedureDocs) deprecationHeader() (string, bool) {
+	since, err := time.Parse(time.DateOnly, docs.deprecatedSince)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("@%d", since.Unix()), true
+}
+
+// writes the doc tag of a struct field as the JSDoc of its typescript property
+func writeFieldDoc(stringBuilder *strings.Builder, doc string) {
+	if doc != "" {
+		stringBuilder.WriteString(" " + jsDoc(strings.Split(doc, "\n")...))
+	}
+}
+
+// the lines of the go doc comment of a procedure in the go client, its deprecation is a "Deprecated:" paragraph as go tools expect it
+func goDocLines(docs procedureDocs) []string {
+	lines := []string{}
+	// the summary and the description stay in the first paragraph, gofmt would turn a paragraph of a single line into a heading
+	for _, text := range []string{docs.summary, docs.description} {
+		if text != "" {
+			lines = append(lines, strings.Split(text, "\n")...)
+		}
+	}
+	if docs.deprecated {
+		lines = append(lines, "", strings.TrimSpace("Deprecated: "+docs.deprecatedMessage()))
+	}
+	return lines
+}
+
+// the lines of the doc comment of a procedure in the swift and kotlin clients, which mark the deprecated procedures with their own annotations
+func clientDocLines(method Method, path string, docs procedureDocs) []string {
+	lines := []string{fmt.Sprintf("Calls the %s at %s", method, path)}
+	if docs.summary != "" || docs.description != "" {
+		lines = append(lines, "")
+	}
+	for _, text := range []string{docs.summary, docs.description} {
+		if text != "" {
+			lines = append(lines, strings.Split(text, "\n")...)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,363 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RegisterEnum registers the only values that a named string or number type can take. The generated typescript then uses a union of those values instead of a plain string or number,
+// for example RegisterEnum(app, StatusActive, StatusDisabled) is generated as "active" | "disabled".
+// Struct fields can also list their values with the enum tag : `enum:"active,disabled"`.
+// Set ValidateEnums in your config to reject queries and inputs that hold any other value
+func RegisterEnum[T comparable](app *App, values ...T) {
+	if len(values) == 0 {
+		panic(fmt.Sprintf("RegisterEnum needs at least one value of %s", reflect.TypeOf((*T)(nil)).Elem()))
+	}
+	enumValues := make([]any, len(values))
+	for i, value := range values {
+		enumValues[i] = value
+	}
+	app.enums[reflect.TypeOf((*T)(nil)).Elem()] = enumValues
+}
+
+// returns the typescript union of a registered enum. Outside of inline registries the union is declared once under the name of the go type
+func (types *tsTypeRegistry) enumType(decl *typeDecl) string {
+	t := decl.goType
+	literals := make([]string, len(decl.values))
+	for i, value := range decl.values {
+		literals[i] = types.tsLiteral(reflect.ValueOf(value))
+	}
+	union := strings.Join(literals, " | ")
+	if types.inline || t.Name() == "" {
+		return union
+	}
+	if name, ok := types.names[t]; ok {
+		return name
+	}
+	name := types.uniqueName(t)
+	types.names[t] = name
+	types.types[name] = t
+	types.declarations[name] = fmt.Sprintf("export type %s = %s", name, union)
+	return name
+}
+
+// returns the typescript union of the values listed in an enum tag. Slices and arrays get an array of that union.
+// It returns false if the field has no enum tag
+func (types *tsTypeRegistry) enumTagType(field fieldModel) (string, bool) {
+	if field.enum == nil {
+		return "", false
+	}
+	valueModel, isList := enumTagValueModel(field.model)
+
+	var literals []string
+	for _, value := range field.enum {
+		if types.sendsText(valueModel) {
+			value = strconv.Quote(value)
+		}
+		literals = append(literals, value)
+	}
+	union := strings.Join(literals, " | ")
+	if isList {
+		return fmt.Sprintf("Array<%s>", union), true
+	}
+	return union, true
+}
+
+// returns the model of the values listed in an enum tag : the model of the field, or of its elements when it is a list
+func enumTagValueModel(model *typeModel) (*typeModel, bool) {
+	if model.kind == kindOptional {
+		model = model.elem
+	}
+	isList := model.kind == kindList
+	if isList {
+		model = model.elem
+	}
+	if model.kind == kindOptional {
+		model = model.elem
+	}
+	return model, isList
+}
+
+// reports if the values of the model are put on the wire as json strings
+func (types *tsTypeRegistry) sendsText(model *typeModel) bool {
+	switch model.kind {
+	case kindString, kindTime:
+		return true
+	case kindInt64:
+		return types.int64Mode.sendsString(reflect.Int64)
+	case kindEnum:
+		return model.decl.goType.Kind() == reflect.String || types.int64Mode.sendsString(model.decl.goType.Kind())
+	}
+	return false
+}
+
+// returns the value as a typescript literal, written the way it is put on the wire
+func (types *tsTypeRegistry) tsLiteral(value reflect.Value) string {
+	if types.int64Mode.sendsString(value.Kind()) {
+		return strconv.Quote(enumValueString(value))
+	}
+	encoded, err := json.Marshal(value.Interface())
+	if err != nil {
+		return strconv.Quote(enumValueString(value))
+	}
+	return string(encoded)
+}
+
+// the values listed in the enum tag of a field, parsed into the kind of the field
+type enumTag struct {
+	// the values the way the values of the field are compared with them : a string, an int64, a uint64, a float64 rounded to the size of the field or a bool
+	values []any
+	// the values written the way encoding/json writes them, 1 for "1.0" or "+1" for example
+	texts []string
+}
+
+type enumTagKey struct {
+	fieldType reflect.Type
+	tag       reflect.StructTag
+}
+
+// the enum tags that were already parsed. Attach parses the tags of every type of a procedure, the requests and the generators then only read them
+var enumTags sync.Map
+
+// returns the enum tag of the field, nil if it has none. Attach refuses the procedures whose types hold an invalid enum tag (see checkEnumTags),
+// an invalid tag that is reached anyway is treated as no tag
+func enumTagOf(field reflect.StructField) *enumTag {
+	tag, _ := parseEnumTag(field)
+	return tag
+}
+
+// returns the values of the enum tag of the field the way they are put on the wire, nil if it has none
+func enumTagTexts(field reflect.StructField) []string {
+	if tag := enumTagOf(field); tag != nil {
+		return tag.texts
+	}
+	return nil
+}
+
+// parses the enum tag of the field the first time. It fails if the tag lists no value or a value that the field can not hold, a number field can only list numbers for example
+func parseEnumTag(field reflect.StructField) (*enumTag, error) {
+	raw, ok := field.Tag.Lookup("enum")
+	if !ok {
+		return nil, nil
+	}
+	key := enumTagKey{fieldType: field.Type, tag: field.Tag}
+	if cached, ok := enumTags.Load(key); ok {
+		return cached.(*enumTag), nil
+	}
+
+	fieldType := field.Type
+	for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+		fieldType = fieldType.Elem()
+	}
+	tag := &enumTag{}
+	for _, text := range strings.Split(raw, ",") {
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		value, err := parseEnumValue(text, fieldType)
+		if err != nil {
+			return nil, fmt.Errorf("the enum tag of the field %s lists %q : %w", field.Name, text, err)
+		}
+		tag.values = append(tag.values, value)
+		tag.texts = append(tag.texts, enumValueString(reflect.ValueOf(value).Convert(fieldType)))
+	}
+	if len(tag.values) == 0 {
+		return nil, fmt.Errorf("the enum tag of the field %s lists no value", field.Name)
+	}
+	enumTags.Store(key, tag)
+	return tag, nil
+}
+
+// parses a value of an enum tag into the kind of the field, see enumTag.values
+func parseEnumValue(text string, t reflect.Type) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return text, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(text, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.ParseUint(text, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(text, t.Bits())
+	case reflect.Bool:
+		return strconv.ParseBool(text)
+	}
+	return nil, fmt.Errorf("a %s can not be an enum, only strings, numbers and booleans can", t)
+}
+
+// returns the value of a field the way it is compared with the values of its enum tag
+func enumTagValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	}
+	return nil
+}
+
+// checks every enum tag of the types and of the types that they hold, so that a typo in a tag fails when the procedure is attached instead of when it is called
+func checkEnumTags(types ...reflect.Type) error {
+	visited := map[reflect.Type]bool{}
+	for _, t := range types {
+		if err := checkTypeEnumTags(t, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkTypeEnumTags(t reflect.Type, visited map[reflect.Type]bool) error {
+	if t == nil || visited[t] {
+		return nil
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return checkTypeEnumTags(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if _, err := parseEnumTag(field); err != nil {
+				return fmt.Errorf("%s : %w", t, err)
+			}
+			if err := checkTypeEnumTags(field.Type, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// returns the text of a string, number or boolean value, the way it is written in an enum tag
+func enumValueString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// checks that every registered enum and every field with an enum tag inside the value holds one of its allowed values. Zero values are left to the required validation
+func (c *Ctx) validateEnums(value any) error {
+	if c.app == nil || !c.app.config.ValidateEnums {
+		return nil
+	}
+	if err := c.app.checkEnumValue(reflect.ValueOf(value), ""); err != nil {
+		return &Error{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func (a *App) checkEnumValue(v reflect.Value, path string) error {
+	if !v.IsValid() {
+		return nil
+	}
+	if values, ok := a.enums[v.Type()]; ok {
+		if v.IsZero() {
+			return nil
+		}
+		allowed := make([]string, len(values))
+		for i, value := range values {
+			if v.Interface() == value {
+				return nil
+			}
+			allowed[i] = enumValueString(reflect.ValueOf(value))
+		}
+		return fmt.Errorf("invalid value %q for %s, it must be one of %s", enumValueString(v), path, strings.Join(allowed, ", "))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return a.checkEnumValue(v.Elem(), path)
+	case reflect.Struct:
+		// the errors name the fields the way the client sends them
+		for _, field := range wireFields(v.Type()) {
+			fieldValue, err := v.FieldByIndexErr(field.index)
+			if err != nil {
+				// the field belongs to an embedded struct pointer that is nil
+				continue
+			}
+			fieldPath := field.name
+			if path != "" {
+				fieldPath = path + "." + field.name
+			}
+			if tag := enumTagOf(field.field); tag != nil {
+				if err := checkEnumTag(fieldValue, tag, fieldPath); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := a.checkEnumValue(fieldValue, fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := a.checkEnumValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := a.checkEnumValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checks a field that lists its allowed values in an enum tag. Every element of slices and arrays is checked
+func checkEnumTag(v reflect.Value, tag *enumTag, path string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEnumTag(v.Index(i), tag, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if v.IsZero() {
+		return nil
+	}
+	value := enumTagValue(v)
+	for _, allowed := range tag.values {
+		if value == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value %q for %s, it must be one of %s", enumValueString(v), path, strings.Join(tag.texts, ", "))
+}
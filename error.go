@@ -3,8 +3,25 @@ package bluerpc
 type Error struct {
 	Code    int
 	Message string
+	// Sent to the client next to the message. Declare its type on the procedure with Throws so that the generated clients know about it
+	Data any
 }
 
 func (bluerpcErr *Error) Error() string {
 	return bluerpcErr.Message
 }
+
+// the json body that an error is sent with
+func (bluerpcErr *Error) body() Map {
+	body := Map{"message": bluerpcErr.Message}
+	if bluerpcErr.Data != nil {
+		body["data"] = bluerpcErr.Data
+	}
+	return body
+}
+
+// an error that a procedure declared with Throws. dataSchema holds an instance of the type of its Data, nil when it has none
+type procedureError struct {
+	code       int
+	dataSchema interface{}
+}
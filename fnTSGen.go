@@ -6,59 +6,41 @@ import (
 	"strings"
 )
 
-func genTSFuncFromQuery(stringBuilder *strings.Builder, query, output interface{}, address string) {
+func genTSFuncFromQuery(stringBuilder *strings.Builder, types *tsTypeRegistry, procedure procedureModel) {
 
 	stringBuilder.WriteString("(")
 
-	var dynamicSlugNames []string
-	// for _, dynamicSlug := range dynamicSlugs {
-	// 	dynamicSlugNames = append(dynamicSlugNames, dynamicSlug.Name)
-	// }
-
-	if !isInterpretedAsEmpty(query) {
-		qpType := getType(query)
+	queryType, hasQuery := getTSQueryType(types, procedure)
+	if hasQuery {
 		stringBuilder.WriteString("query:")
-		stringBuilder.WriteString(goToTsObj(qpType, dynamicSlugNames...))
+		stringBuilder.WriteString(queryType)
 		stringBuilder.WriteString(",")
 
 	}
 	stringBuilder.WriteString("headers?: HeadersInit,")
 	stringBuilder.WriteString("):Promise<")
 
-	generateFnOutputType(stringBuilder, output, dynamicSlugNames...)
-	// address = addDynamicToAddress(address, QUERY, dynamicSlugs)
-	generateQueryFnBody(stringBuilder, query != nil, address)
+	generateFnOutputType(stringBuilder, types, procedure)
+	address := addDynamicToAddress(procedure.path, QUERY, findDynamicSlugs(procedure.path))
+	generateQueryFnBody(stringBuilder, hasQuery, address, types.responseWrappers(false, procedure))
 }
 
-func genTSFuncFromMutation(stringBuilder *strings.Builder, query, input, output interface{}, address string) {
+func genTSFuncFromMutation(stringBuilder *strings.Builder, types *tsTypeRegistry, procedure procedureModel) {
 
 	stringBuilder.WriteString("(")
 
-	var dynamicSlugNames []string
-	// for _, dynamicSlug := range dynamicSlugs {
-	// 	dynamicSlugNames = append(dynamicSlugNames, dynamicSlug.Name)
-	// }
-
-	isParams := !isInterpretedAsEmpty(query) || !isInterpretedAsEmpty(input)
+	queryType, hasQuery := getTSQueryType(types, procedure)
+	isParams := hasQuery || procedure.input != nil
 
 	if isParams {
 		stringBuilder.WriteString("parameters : {")
 	}
 
-	if !isInterpretedAsEmpty(query) {
-		qpType := getType(query)
-		if qpType.Kind() == reflect.Ptr {
-			qpType = qpType.Elem()
-		}
-
-		stringBuilder.WriteString(fmt.Sprintf("query:%s,", goToTsObj(qpType, dynamicSlugNames...)))
+	if hasQuery {
+		stringBuilder.WriteString(fmt.Sprintf("query:%s,", queryType))
 	}
-	if !isInterpretedAsEmpty(input) {
-		inputType := getType(input)
-		if inputType.Kind() == reflect.Ptr {
-			inputType = inputType.Elem()
-		}
-		stringBuilder.WriteString(fmt.Sprintf("input:%s", goToTsObj(inputType, dynamicSlugNames...)))
+	if procedure.input != nil {
+		stringBuilder.WriteString(fmt.Sprintf("input:%s", types.tsType(procedure.input)))
 	}
 
 	if isParams {
@@ -67,31 +49,69 @@ func genTSFuncFromMutation(stringBuilder *strings.Builder, query, input, output
 	stringBuilder.WriteString("headers?: HeadersInit,")
 
 	stringBuilder.WriteString("):Promise<")
-	generateFnOutputType(stringBuilder, output, dynamicSlugNames...)
-	// address = addDynamicToAddress(address, MUTATION, dynamicSlugs)
-	generateMutationFnBody(stringBuilder, isParams, address)
-}
-func generateFnOutputType(stringBuilder *strings.Builder, output any, dynamicSlugNames ...string) {
-	if output != nil {
-		outputType := getType(output)
-		var tsType string
-		if outputType.Kind() == reflect.Struct {
-			tsType = goToTsObj(outputType, dynamicSlugNames...)
-		} else {
-			tsType = goTypeToTSType(outputType)
+	generateFnOutputType(stringBuilder, types, procedure)
+	address := addDynamicToAddress(procedure.path, MUTATION, findDynamicSlugs(procedure.path))
+	generateMutationFnBody(stringBuilder, isParams, address, procedure.httpMethod, types.responseWrappers(false, procedure))
+}
+func genTSFuncFromSubscription(stringBuilder *strings.Builder, types *tsTypeRegistry, procedure procedureModel) {
+
+	stringBuilder.WriteString("(")
+
+	queryType, hasQuery := getTSQueryType(types, procedure)
+	if hasQuery {
+		stringBuilder.WriteString("query:")
+		stringBuilder.WriteString(queryType)
+		stringBuilder.WriteString(",")
+	}
+	stringBuilder.WriteString("headers?: HeadersInit,")
+	stringBuilder.WriteString(fmt.Sprintf("):RpcSubscription<%s>=>", types.tsType(procedure.output)))
+	address := addDynamicToAddress(procedure.path, SUBSCRIPTION, findDynamicSlugs(procedure.path))
+	generateSubscriptionFnBody(stringBuilder, hasQuery, address, types.responseWrappers(true, procedure))
+}
+
+// returns the typescript type of the query parameters of a procedure and whether the procedure takes any query at all.
+// Every dynamic slug of the procedure's address becomes a required field ending in Slug, typed after the matching query field when there is one and as a string otherwise
+// Query parameters are always written inline since they are a flat set of url parameters rather than a shared body type
+func getTSQueryType(types *tsTypeRegistry, procedure procedureModel) (string, bool) {
+	if procedure.query == nil && len(procedure.slugs) == 0 {
+		return "", false
+	}
+	return types.queryObject(procedure.query, procedure.slugs), true
+}
+
+// writes the response type of a procedure. Its error is typed after the errors the procedure declared with Throws (see getTSErrorType)
+func generateFnOutputType(stringBuilder *strings.Builder, types *tsTypeRegistry, procedure procedureModel) {
+	stringBuilder.WriteString("RpcResponse<" + types.tsType(procedure.output))
+	if errorType := getTSErrorType(types, procedure.errors); errorType != "" {
+		stringBuilder.WriteString(", " + errorType)
+	}
+	stringBuilder.WriteString(">>=>")
+}
+
+// returns the union of the errors declared with Throws, discriminated by their status, or "" when the procedure declared none.
+// Errors that were not declared can still happen (a failed validation, an authorizer...), they are typed as RpcUnknownError
+func getTSErrorType(types *tsTypeRegistry, errors []errorModel) string {
+	if len(errors) == 0 {
+		return ""
+	}
+	variants := make([]string, 0, len(errors)+1)
+	for _, procErr := range errors {
+		data := "data?: undefined"
+		if procErr.data != nil {
+			data = "data: " + types.tsType(procErr.data)
 		}
-		stringBuilder.WriteString(fmt.Sprintf("{body:%s,", tsType))
-	} else {
-		stringBuilder.WriteString("body:void,")
+		variants = append(variants, fmt.Sprintf("{ status: %d; message: string; %s }", procErr.code, data))
 	}
-	stringBuilder.WriteString("status: number, headers: Headers")
-	stringBuilder.WriteString("}>=>")
+	return strings.Join(append(variants, "RpcUnknownError"), " | ")
 }
 
-// hasQuery here refers to if there's a query params variable placed
-func generateQueryFnBody(stringBuilder *strings.Builder, hasQuery bool, address string) {
+// hasQuery here refers to if there's a query params variable placed.
+// The wrappers are the functions that the response goes through before it is returned (see responseWrappers)
+func generateQueryFnBody(stringBuilder *strings.Builder, hasQuery bool, address string, wrappers []responseWrapper) {
 
-	stringBuilder.WriteString("{return rpcCall(")
+	stringBuilder.WriteString("{return ")
+	writeWrappersStart(stringBuilder, wrappers)
+	stringBuilder.WriteString("rpcCall(")
 	stringBuilder.WriteString("`" + address + "`")
 	stringBuilder.WriteString(",'GET',")
 	if hasQuery {
@@ -100,20 +120,93 @@ func generateQueryFnBody(stringBuilder *strings.Builder, hasQuery bool, address
 		stringBuilder.WriteString("undefined")
 	}
 	stringBuilder.WriteString(",headers")
-	stringBuilder.WriteString(")}")
+	stringBuilder.WriteString(")")
+	writeWrappersEnd(stringBuilder, wrappers)
+	stringBuilder.WriteString("}")
 
 }
-func generateMutationFnBody(stringBuilder *strings.Builder, isParams bool, address string) {
-	stringBuilder.WriteString("{return rpcCall(")
+func generateSubscriptionFnBody(stringBuilder *strings.Builder, hasQuery bool, address string, wrappers []responseWrapper) {
+	stringBuilder.WriteString("{return ")
+	writeWrappersStart(stringBuilder, wrappers)
+	stringBuilder.WriteString("rpcSubscribe(")
+	stringBuilder.WriteString("`" + address + "`")
+	stringBuilder.WriteString(",")
+	if hasQuery {
+		stringBuilder.WriteString("{query}")
+	} else {
+		stringBuilder.WriteString("undefined")
+	}
+	stringBuilder.WriteString(",headers")
+	stringBuilder.WriteString(")")
+	writeWrappersEnd(stringBuilder, wrappers)
+	stringBuilder.WriteString("}")
+}
+func generateMutationFnBody(stringBuilder *strings.Builder, isParams bool, address string, httpMethod string, wrappers []responseWrapper) {
+	stringBuilder.WriteString("{return ")
+	writeWrappersStart(stringBuilder, wrappers)
+	stringBuilder.WriteString("rpcCall(")
 	stringBuilder.WriteString("`" + address + "`")
-	stringBuilder.WriteString(",'POST',")
+	stringBuilder.WriteString(",'" + httpMethod + "',")
 	if isParams {
 		stringBuilder.WriteString("parameters")
 	} else {
 		stringBuilder.WriteString("undefined")
 	}
 	stringBuilder.WriteString(",headers")
-	stringBuilder.WriteString(")}")
+	stringBuilder.WriteString(")")
+	writeWrappersEnd(stringBuilder, wrappers)
+	stringBuilder.WriteString("}")
+}
+
+// a function that the response of a procedure goes through before it is returned, called with the response and its argument
+type responseWrapper struct {
+	fn  string
+	arg string
+}
+
+// returns the wrappers of a procedure's response, innermost first. The bigints are revived first (see Int64AsBigInt) and the result is then validated against the zod schema (see Config.GenerateZod).
+// The bigints of the data of the declared errors are revived as well. The zod schemas of the procedure are recorded in rpcSchemas along the way
+func (types *tsTypeRegistry) responseWrappers(isSubscription bool, procedure procedureModel) []responseWrapper {
+	var wrappers []responseWrapper
+	spec := types.bigIntSpec(procedure.output)
+	errorSpecs := ""
+	if !isSubscription {
+		for _, procErr := range procedure.errors {
+			if errorSpec := types.bigIntSpec(procErr.data); errorSpec != "" {
+				errorSpecs += fmt.Sprintf("%d:%s,", procErr.code, errorSpec)
+			}
+		}
+	}
+	if isSubscription && spec != "" {
+		wrappers = append(wrappers, responseWrapper{fn: "reviveSubscription", arg: spec})
+	} else if errorSpecs != "" {
+		if spec == "" {
+			spec = "undefined"
+		}
+		wrappers = append(wrappers, responseWrapper{fn: "reviveResponse", arg: spec + ",{" + errorSpecs + "}"})
+	} else if spec != "" {
+		wrappers = append(wrappers, responseWrapper{fn: "reviveResponse", arg: spec})
+	}
+	if schema := types.addProcedureSchemas(procedure); schema != "" {
+		fn := "validateResponse"
+		if isSubscription {
+			fn = "validateSubscription"
+		}
+		wrappers = append(wrappers, responseWrapper{fn: fn, arg: schema})
+	}
+	return wrappers
+}
+
+func writeWrappersStart(stringBuilder *strings.Builder, wrappers []responseWrapper) {
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		stringBuilder.WriteString(wrappers[i].fn + "(")
+	}
+}
+
+func writeWrappersEnd(stringBuilder *strings.Builder, wrappers []responseWrapper) {
+	for _, wrapper := range wrappers {
+		stringBuilder.WriteString("," + wrapper.arg + ")")
+	}
 }
 
 func getType(t interface{}) reflect.Type {
@@ -136,24 +229,37 @@ func getType(t interface{}) reflect.Type {
 }
 
 // adds the needed dynamic typescript string to the address in the generated ts.
-// func addDynamicToAddress(s string, method Method, slugInfos []dynamicSlugInfo) string {
-// 	if len(slugInfos) == 0 {
-// 		return s
-// 	}
-// 	splitStr := strings.Split(s, "/")
-
-// 	for _, dsi := range slugInfos {
-// 		var dynTsPart string
-// 		switch method {
-// 		case QUERY:
-// 			dynTsPart = fmt.Sprintf(`query.%sSlug`, dsi.Name)
-// 		case MUTATION:
-// 			dynTsPart = fmt.Sprintf(`parameters.query.%sSlug`, dsi.Name)
-// 		}
-// 		splitStr[len(splitStr)-1-dsi.Position] = fmt.Sprintf(`${%s}`, dynTsPart)
-// 	}
-// 	return strings.Join(splitStr, "/")
-// }
+// Every {slug} of the address is replaced by the value of the matching Slug field of the query. Wildcards that span multiple segments ({slug...}) keep their slashes
+func addDynamicToAddress(s string, method Method, dynamicSlugNames []string) string {
+	// {$} only anchors the end of the path
+	s = strings.ReplaceAll(s, "{$}", "")
+	if len(dynamicSlugNames) == 0 {
+		return s
+	}
+	splitStr := strings.Split(s, "/")
+
+	for i, part := range splitStr {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+		encodeFn := "encodeURIComponent"
+		if strings.HasSuffix(name, "...") {
+			name = strings.TrimSuffix(name, "...")
+			encodeFn = "encodeURI"
+		}
+
+		var dynTsPart string
+		switch method {
+		case MUTATION:
+			dynTsPart = fmt.Sprintf(`parameters.query.%sSlug`, name)
+		default:
+			dynTsPart = fmt.Sprintf(`query.%sSlug`, name)
+		}
+		splitStr[i] = fmt.Sprintf(`${%s(String(%s))}`, encodeFn, dynTsPart)
+	}
+	return strings.Join(splitStr, "/")
+}
 
 func isInterpretedAsEmpty(v interface{}) bool {
 	// First, check if v is nil. This covers the case where v is nil itself.
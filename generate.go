@@ -0,0 +1,178 @@
+package bluerpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// The environment variable that the bluerpc gen command (see cmd/bluerpc) runs your program with and that RunGen reads :
+// "write" in order to write every client or "check" in order to fail when one of them differs from the file on disk
+const GenerateEnv = "BLUERPC_GEN"
+
+// returns the comment that every generated file starts with, written with the comment prefix of its language.
+// It names the module of the program that generated the file when it is known, so that the file can be traced back to its server
+func generatedHeader(commentPrefix string) string {
+	header := commentPrefix + " Code generated by bluerpc. DO NOT EDIT.\n"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+		header += commentPrefix + " Source: the procedures of " + info.Main.Path + ", regenerate it with bluerpc gen\n"
+	}
+	return header
+}
+
+// a file generated from the procedures of the app
+type generatedFile struct {
+	path     string
+	generate func() ([]byte, error)
+}
+
+// returns every file that the config asks for : the typescript client, the OpenAPI document and the go, python, swift and kotlin clients, the schema, then the files of the generators added with AddGenerator
+func (a *App) generatedFiles() []generatedFile {
+	var files []generatedFile
+	if !a.config.DisableGenerateTS {
+		files = append(files, a.tsFiles(a.config.OutputPath)...)
+	}
+	if a.config.OpenAPIOutputPath != "" {
+		files = append(files, generatedFile{path: a.config.OpenAPIOutputPath, generate: a.OpenAPI})
+	}
+	if a.config.GoClientOutputPath != "" {
+		outputPath := a.config.GoClientOutputPath
+		files = append(files, generatedFile{path: outputPath, generate: func() ([]byte, error) {
+			return a.goClientFile(outputPath)
+		}})
+	}
+	if a.config.PythonClientOutputPath != "" {
+		files = append(files, generatedFile{path: a.config.PythonClientOutputPath, generate: a.GeneratePythonClient})
+	}
+	if a.config.SwiftClientOutputPath != "" {
+		files = append(files, generatedFile{path: a.config.SwiftClientOutputPath, generate: a.GenerateSwiftClient})
+	}
+	if a.config.KotlinClientOutputPath != "" {
+		outputPath := a.config.KotlinClientOutputPath
+		files = append(files, generatedFile{path: outputPath, generate: func() ([]byte, error) {
+			return a.kotlinClientFile(outputPath)
+		}})
+	}
+	if a.config.SchemaOutputPath != "" {
+		files = append(files, generatedFile{path: a.config.SchemaOutputPath, generate: func() ([]byte, error) {
+			return json.MarshalIndent(a.Schema(), "", "  ")
+		}})
+	}
+	for _, plugin := range a.generators {
+		generator := plugin.generator
+		files = append(files, generatedFile{path: plugin.outputPath, generate: func() ([]byte, error) {
+			return generator.Generate(a.Schema())
+		}})
+	}
+	return files
+}
+
+// returns the files of the typescript client written at path, a single one unless Config.SplitTSOutput is set
+func (a *App) tsFiles(path string) []generatedFile {
+	if !a.config.SplitTSOutput {
+		return []generatedFile{{path: path, generate: func() ([]byte, error) {
+			return []byte(generateTsSource(a)), nil
+		}}}
+	}
+	var files []generatedFile
+	for _, module := range generateTsModules(a, path) {
+		source := module.source
+		files = append(files, generatedFile{path: filepath.Join(filepath.Dir(path), module.name+filepath.Ext(path)), generate: func() ([]byte, error) {
+			return []byte(source), nil
+		}})
+	}
+	return files
+}
+
+// Writes the typescript client to path. The server does not need to be started.
+// With Config.SplitTSOutput path is the index and the other modules are written next to it
+func (a *App) GenerateTS(path string) error {
+	for _, file := range a.tsFiles(path) {
+		content, err := file.generate()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.path, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes every client that the config asks for (OutputPath, OpenAPIOutputPath, GoClientOutputPath, PythonClientOutputPath, SwiftClientOutputPath, KotlinClientOutputPath, SchemaOutputPath and the generators added with AddGenerator) without starting the server.
+// Listen calls it as well, but only reports its errors so that a server that can not write them still starts
+func (a *App) WriteClients() error {
+	for _, file := range a.generatedFiles() {
+		content, err := file.generate()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.path, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generates every client that the config asks for and compares them with the files on disk. It returns an error naming the files that are missing or out of date,
+// which lets CI catch the clients that were not regenerated after a change of the procedures
+func (a *App) CheckClients() error {
+	var outdated []string
+	for _, file := range a.generatedFiles() {
+		content, err := file.generate()
+		if err != nil {
+			return err
+		}
+		onDisk, err := os.ReadFile(file.path)
+		if err != nil || !bytes.Equal(onDisk, content) {
+			outdated = append(outdated, file.path)
+		}
+	}
+	if len(outdated) > 0 {
+		return fmt.Errorf("the generated clients are out of date, regenerate them with bluerpc gen : %s", strings.Join(outdated, ", "))
+	}
+	return nil
+}
+
+// RunGen is the entry point of the bluerpc gen command. When the program was started by the command it writes or checks every client, depending on GenerateEnv, and returns true.
+// The program should then exit instead of serving. Call it once every procedure is attached, before Listen :
+//
+//	if ran, err := app.RunGen(); ran {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+//	app.Listen(":8080")
+func (a *App) RunGen() (bool, error) {
+	mode := os.Getenv(GenerateEnv)
+	if mode == "" {
+		return false, nil
+	}
+	var err error
+	switch mode {
+	case "write":
+		err = a.WriteClients()
+	case "check":
+		err = a.CheckClients()
+	default:
+		err = fmt.Errorf("unknown %s mode %q, expected write or check", GenerateEnv, mode)
+	}
+	if err != nil {
+		return true, err
+	}
+	if !a.config.DisableInfoPrinting {
+		for _, file := range a.generatedFiles() {
+			if mode == "check" {
+				fmt.Println(DefaultColors.Green + file.path + " is up to date" + DefaultColors.Reset)
+			} else {
+				fmt.Println(DefaultColors.Green + "generated " + file.path + DefaultColors.Reset)
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,268 @@
+package bluerpc
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteAndCheckClients(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING WRITE AND CHECK CLIENTS" + DefaultColors.Reset)
+	dir := t.TempDir()
+	app := New(&Config{
+		OutputPath:          filepath.Join(dir, "output.ts"),
+		OpenAPIOutputPath:   filepath.Join(dir, "openapi.json"),
+		GoClientOutputPath:  filepath.Join(dir, "client", "client.go"),
+		DisableInfoPrinting: true,
+	})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users")
+
+	if err := app.CheckClients(); err == nil || !strings.Contains(err.Error(), "output.ts") {
+		t.Fatalf(DefaultColors.Red+"Expected the missing clients to be reported, got %v", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "client"), 0755); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not create the client directory : %s", err.Error())
+	}
+	if err := app.WriteClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not write the clients : %s", err.Error())
+	}
+	if err := app.CheckClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected the written clients to be up to date, got %s", err.Error())
+	}
+
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users/create")
+	err := app.CheckClients()
+	if err == nil {
+		t.Fatalf(DefaultColors.Red + "Expected the clients to be out of date after adding a procedure")
+	}
+	for _, file := range []string{"output.ts", "openapi.json", "client.go"} {
+		if !strings.Contains(err.Error(), file) {
+			t.Fatalf(DefaultColors.Red+"Expected %s to be out of date, got %s", file, err.Error())
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED WRITE AND CHECK CLIENTS" + DefaultColors.Reset)
+}
+
+func TestSplitTSOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING SPLIT TS OUTPUT" + DefaultColors.Reset)
+	dir := t.TempDir()
+	app := New(&Config{
+		OutputPath:          filepath.Join(dir, "index.ts"),
+		SplitTSOutput:       true,
+		DisableInfoPrinting: true,
+	})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/me")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_order], error) {
+		return nil, nil
+	}).Attach(app, "/orders/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[any], error) {
+		return nil, nil
+	}).Attach(app, "/user-settings/name")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, nil
+	}).Attach(app, "/types/list")
+
+	if err := app.WriteClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not write the clients : %s", err.Error())
+	}
+	expected := map[string][]string{
+		"runtime.ts": {"export async function clientCall<T>(", "export class RpcError"},
+		"types.ts":   {"export interface tsgen_test_order {"},
+		"root.ts": {
+			`import { type Method, type RpcResponse, type RpcClientOptions, clientCall, type RpcSubscription, clientSubscribe } from "./runtime";`,
+			`import { type tsgen_test_user } from "./types";`,
+			"export function createRootAPI(client: RpcClientOptions) {",
+			"export const root = /* @__PURE__ */ createRootAPI({});",
+		},
+		"orders.ts":       {`import { type tsgen_test_order } from "./types";`, "export const orders = /* @__PURE__ */ createOrdersAPI({});"},
+		"userSettings.ts": {"export const userSettings = /* @__PURE__ */ createUserSettingsAPI({});"},
+		"typesRouter.ts":  {"export const typesRouter = /* @__PURE__ */ createTypesRouterAPI({});"},
+		"index.ts": {
+			`export { createOrdersAPI, orders } from "./orders";`,
+			`import { type RpcClientOptions } from "./runtime";`,
+			"    ...createRootAPI(client),\n    [`orders`]: createOrdersAPI(client),\n    [`types`]: createTypesRouterAPI(client),\n    [`user-settings`]: createUserSettingsAPI(client),\n",
+			"export const rpcAPI = createRpcAPI({});",
+		},
+	}
+	for file, parts := range expected {
+		source, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf(DefaultColors.Red+"Expected %s to be written : %s", file, err.Error())
+		}
+		for _, part := range parts {
+			if !strings.Contains(string(source), part) {
+				t.Fatalf(DefaultColors.Red+"Missing %s in %s : %s", part, file, source)
+			}
+		}
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "index.ts"))
+	if strings.Contains(string(index), "clientCall") || strings.Contains(string(index), "export { createRpcAPI") {
+		t.Fatalf(DefaultColors.Red+"Expected the index to only re-export the public declarations : %s", index)
+	}
+	if err := app.CheckClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected the split client to be up to date, got %s", err.Error())
+	}
+
+	// every router module holds the zod schemas of its own procedures, types only holds the schemas of the named types
+	zodApp := New(&Config{OutputPath: filepath.Join(dir, "zod", "index.ts"), SplitTSOutput: true, GenerateZod: true, DisableInfoPrinting: true})
+	NewQuery(zodApp, func(ctx *Ctx, query any) (*Res[tsgen_test_order], error) {
+		return nil, nil
+	}).Attach(zodApp, "/orders/{id}")
+	NewMutation(zodApp, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(zodApp, "/users/create")
+	modules := map[string]string{}
+	for _, module := range generateTsModules(zodApp, filepath.Join(dir, "zod", "index.ts")) {
+		modules[module.name] = module.source
+	}
+	if strings.Contains(modules["types"], "rpcSchemas") {
+		t.Fatalf(DefaultColors.Red+"Expected the schemas of the procedures to leave types.ts : %s", modules["types"])
+	}
+	for module, parts := range map[string][]string{
+		"orders": {"export const ordersSchemas = {\n  \"/orders/{id}\": {", `ordersSchemas["/orders/{id}"].output`},
+		"users":  {"export const usersSchemas = {\n  \"/users/create\": {", `usersSchemas["/users/create"].output`},
+		"index":  {`import { ordersSchemas, createOrdersAPI } from "./orders";`, "export const rpcSchemas = {\n  ...ordersSchemas,\n  ...usersSchemas,\n};"},
+	} {
+		for _, part := range parts {
+			if !strings.Contains(modules[module], part) {
+				t.Fatalf(DefaultColors.Red+"Missing %s in %s : %s", part, module, modules[module])
+			}
+		}
+	}
+	if strings.Contains(modules["orders"], "usersSchemas") {
+		t.Fatalf(DefaultColors.Red+"Expected the orders module to only hold its own schemas : %s", modules["orders"])
+	}
+	fmt.Println(DefaultColors.Green + "PASSED SPLIT TS OUTPUT" + DefaultColors.Reset)
+}
+
+func TestRunGen(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING RUN GEN" + DefaultColors.Reset)
+	dir := t.TempDir()
+	app := New(&Config{
+		OutputPath:          filepath.Join(dir, "output.ts"),
+		DisableInfoPrinting: true,
+	})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users")
+
+	t.Setenv(GenerateEnv, "")
+	if ran, err := app.RunGen(); ran || err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected RunGen to do nothing outside of bluerpc gen, got %t %v", ran, err)
+	}
+
+	t.Setenv(GenerateEnv, "write")
+	if ran, err := app.RunGen(); !ran || err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected RunGen to write the clients, got %t %v", ran, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "output.ts")); err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected the typescript client to be written : %s", err.Error())
+	}
+
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users/create")
+	t.Setenv(GenerateEnv, "check")
+	if ran, err := app.RunGen(); !ran || err == nil {
+		t.Fatalf(DefaultColors.Red+"Expected RunGen to report the out of date client, got %t %v", ran, err)
+	}
+
+	t.Setenv(GenerateEnv, "build")
+	if _, err := app.RunGen(); err == nil {
+		t.Fatalf(DefaultColors.Red + "Expected an unknown mode to be an error")
+	}
+	fmt.Println(DefaultColors.Green + "PASSED RUN GEN" + DefaultColors.Reset)
+}
+
+func TestCheckClientsAfterListen(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING CHECK CLIENTS AFTER LISTEN" + DefaultColors.Reset)
+	dir := t.TempDir()
+	app := New(&Config{
+		ServerURL:              "http://localhost:3004",
+		OutputPath:             filepath.Join(dir, "output.ts"),
+		OpenAPIOutputPath:      filepath.Join(dir, "openapi.json"),
+		PythonClientOutputPath: filepath.Join(dir, "client.py"),
+		SwiftClientOutputPath:  filepath.Join(dir, "Client.swift"),
+		KotlinClientOutputPath: filepath.Join(dir, "Client.kt"),
+		DisableInfoPrinting:    true,
+	})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return &Res[tsgen_test_user]{}, nil
+	}).Attach(app, "/users")
+
+	// bluerpc gen writes the clients without listening, the server then writes them again when it starts. Both must give the same files
+	if err := app.WriteClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not write the clients : %s", err.Error())
+	}
+	if err := app.CheckClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected the written clients to be up to date, got %s", err.Error())
+	}
+	written := map[string]string{}
+	for _, file := range app.generatedFiles() {
+		content, _ := os.ReadFile(file.path)
+		written[file.path] = string(content)
+	}
+
+	go app.Listen(":3004")
+	defer app.Shutdown()
+	if err := waitForServerReady(":3004"); err != nil {
+		t.Fatalf(DefaultColors.Red+"Server did not start : %s", err.Error())
+	}
+	res, err := http.Get("http://localhost:3004/users")
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not call the server : %s", err.Error())
+	}
+	res.Body.Close()
+
+	if err := app.CheckClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Expected the clients to be up to date once the server listens, got %s", err.Error())
+	}
+	for path, content := range written {
+		if rewritten, _ := os.ReadFile(path); string(rewritten) != content {
+			t.Fatalf(DefaultColors.Red+"The server rewrote %s differently : %s", path, rewritten)
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED CHECK CLIENTS AFTER LISTEN" + DefaultColors.Reset)
+}
+
+func TestListenWithUnwritableClients(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING LISTEN WITH UNWRITABLE CLIENTS" + DefaultColors.Reset)
+	app := New(&Config{
+		OutputPath:          filepath.Join(t.TempDir(), "missing", "output.ts"),
+		DisableInfoPrinting: true,
+	})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return &Res[tsgen_test_user]{}, nil
+	}).Attach(app, "/users")
+
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- app.Listen(":3003") }()
+	defer app.Shutdown()
+	if err := waitForServerReady(":3003"); err != nil {
+		select {
+		case err := <-listenErr:
+			t.Fatalf(DefaultColors.Red+"Expected the server to start without its clients, got %v", err)
+		default:
+			t.Fatalf(DefaultColors.Red+"Server did not start : %s", err.Error())
+		}
+	}
+	res, err := http.Get("http://localhost:3003/users")
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not call the server : %s", err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf(DefaultColors.Red+"Expected the server to serve /users, got %d", res.StatusCode)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED LISTEN WITH UNWRITABLE CLIENTS" + DefaultColors.Reset)
+}
@@ -5,6 +5,7 @@ go 1.22.1
 require (
 	github.com/go-playground/validator/v10 v10.17.0
 	github.com/gorilla/schema v1.2.1
+	golang.org/x/net v0.8.0
 )
 
 require (
@@ -13,7 +14,6 @@ require (
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/leodido/go-urn v1.2.4 // indirect
 	golang.org/x/crypto v0.7.0 // indirect
-	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/sys v0.6.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
 )
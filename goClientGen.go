@@ -0,0 +1,438 @@
+package bluerpc
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const bluerpcImportPath = "github.com/blue-rpc/bluerpc"
+
+// goClientGenerator writes the typed go client of an app. The query, input and output types are imported from their package when they can be,
+// the ones that can't (types of package main, unexported or generic types) are copied into the generated file with the same fields and tags
+type goClientGenerator struct {
+	app    *App
+	models *typeModels
+
+	// import path -> alias
+	imports map[string]string
+	aliases map[string]bool
+
+	names        map[reflect.Type]string
+	takenNames   map[string]bool
+	declarations []string
+}
+
+// GenerateGoClient returns the source of a typed go client for every procedure of the app, in a package called packageName.
+// The client mirrors the router tree : the query attached at /users/{id} is called with client.Users.ById(ctx, query) and uses the same structs as the server
+func (a *App) GenerateGoClient(packageName string) ([]byte, error) {
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("%q is not a valid package name", packageName)
+	}
+	g := &goClientGenerator{
+		app:        a,
+		models:     newTypeModels(a),
+		imports:    map[string]string{"context": "context", bluerpcImportPath: "bluerpc"},
+		aliases:    map[string]bool{"context": true, "bluerpc": true},
+		names:      map[reflect.Type]string{},
+		takenNames: map[string]bool{"New": true, "NewWithClient": true},
+	}
+
+	routers := &strings.Builder{}
+	reserveClientRouterNames(a.startRoute, "Client", g.takenNames)
+	g.writeRouter(routers, a.startRoute, "Client", "")
+
+	source := &strings.Builder{}
+	source.WriteString(generatedHeader("//") + "\n")
+	source.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+	source.WriteString("import (\n")
+	for _, importPath := range getSortedKeys(g.imports) {
+		source.WriteString(fmt.Sprintf("\t%s %q\n", g.imports[importPath], importPath))
+	}
+	source.WriteString(")\n\n")
+
+	source.WriteString("// New creates a client for the server at baseURL\n")
+	source.WriteString("func New(baseURL string) *Client {\n")
+	source.WriteString("\trpc := bluerpc.NewClient(baseURL)\n")
+	if mode := a.config.Int64Mode; mode != "" && mode != Int64AsNumber {
+		source.WriteString(fmt.Sprintf("\trpc.Int64Mode = %s\n", goInt64ModeName(mode)))
+	}
+	source.WriteString("\treturn NewWithClient(rpc)\n}\n\n")
+	source.WriteString("// NewWithClient creates a client that sends every call through rpc. Use it to set the http client or the headers of every call\n")
+	source.WriteString("func NewWithClient(rpc *bluerpc.Client) *Client {\n\treturn newClient(rpc)\n}\n\n")
+
+	source.WriteString(routers.String())
+	for _, declaration := range g.declarations {
+		source.WriteString(declaration)
+		source.WriteString("\n\n")
+	}
+
+	formatted, err := format.Source([]byte(source.String()))
+	if err != nil {
+		return nil, fmt.Errorf("the generated go client is invalid: %v", err)
+	}
+	return formatted, nil
+}
+
+// generates the go client of a file. The package name is the name of the file's directory unless Config.GoClientPackage is set
+func (a *App) goClientFile(outputPath string) ([]byte, error) {
+	packageName := a.config.GoClientPackage
+	if packageName == "" {
+		packageName = goPackageName(filepath.Base(filepath.Dir(outputPath)))
+	}
+	return a.GenerateGoClient(packageName)
+}
+
+// the router client type names are reserved first so that the declared types never take them
+func reserveClientRouterNames(router *Router, typeName string, takenNames map[string]bool) {
+	takenNames[typeName] = true
+	for _, member := range routerMembers(router) {
+		if member.router != nil {
+			reserveClientRouterNames(member.router, clientRouterTypeName(typeName, member), takenNames)
+		}
+	}
+}
+
+// returns the type name of the client of a sub router : the router at /members of UsersClient is called by UsersMembersClient
+func clientRouterTypeName(parentTypeName string, member goClientMember) string {
+	return strings.TrimSuffix(parentTypeName, "Client") + member.name + "Client"
+}
+
+// a field (sub router) or a method (procedure) of a router client
+type goClientMember struct {
+	name   string
+	slug   string
+	router *Router
+	proc   *ProcedureInfo
+}
+
+// returns the sub routers and the procedures of the router along with their go names, sub routers first
+func routerMembers(router *Router) []goClientMember {
+	var members []goClientMember
+	taken := map[string]bool{"rpc": true}
+	add := func(member goClientMember) {
+		name := member.name
+		for i := 2; taken[name]; i++ {
+			name = member.name + strconv.Itoa(i)
+		}
+		taken[name] = true
+		member.name = name
+		members = append(members, member)
+	}
+	for _, slug := range getSortedKeys(router.routes) {
+		add(goClientMember{name: goMemberName(slug, "Router"), slug: slug, router: router.routes[slug]})
+	}
+	for _, slug := range getSortedKeys(router.procedures) {
+		proc := router.procedures[slug]
+		if proc.method == STATIC {
+			continue
+		}
+		add(goClientMember{name: goMemberName(slug, toPascalCase(string(proc.method))), slug: slug, proc: proc})
+	}
+	return members
+}
+
+// turns a slug into an exported go name. /users becomes Users and /{id} becomes ById. Slugs without any letter (/) take the fallback
+func goMemberName(slug string, fallback string) string {
+	name := ""
+	for _, segment := range strings.Split(slug, "/") {
+		if strings.HasPrefix(segment, "{") {
+			segment = strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"), "...")
+			if segment == "$" {
+				continue
+			}
+			name += "By"
+		}
+		name += toPascalCase(segment)
+	}
+	if name == "" {
+		return fallback
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "P" + name
+	}
+	return name
+}
+
+func (g *goClientGenerator) writeRouter(stringBuilder *strings.Builder, router *Router, typeName string, currentPath string) {
+	members := routerMembers(router)
+	constructor := "new" + typeName
+
+	location := currentPath
+	if location == "" {
+		location = "/"
+	}
+	stringBuilder.WriteString(fmt.Sprintf("// %s calls the procedures under %s\n", typeName, location))
+	stringBuilder.WriteString(fmt.Sprintf("type %s struct {\n\trpc *bluerpc.Client\n", typeName))
+	for _, member := range members {
+		if member.router != nil {
+			stringBuilder.WriteString(fmt.Sprintf("\t%s *%s\n", member.name, clientRouterTypeName(typeName, member)))
+		}
+	}
+	stringBuilder.WriteString("}\n\n")
+
+	stringBuilder.WriteString(fmt.Sprintf("func %s(rpc *bluerpc.Client) *%s {\n\treturn &%s{\n\t\trpc: rpc,\n", constructor, typeName, typeName))
+	for _, member := range members {
+		if member.router != nil {
+			stringBuilder.WriteString(fmt.Sprintf("\t\t%s: new%s(rpc),\n", member.name, clientRouterTypeName(typeName, member)))
+		}
+	}
+	stringBuilder.WriteString("\t}\n}\n\n")
+
+	for _, member := range members {
+		if member.proc != nil {
+			g.writeProcedure(stringBuilder, typeName, member.name, g.models.procedure(member.proc, currentPath+member.slug))
+		}
+	}
+	for _, member := range members {
+		if member.router != nil {
+			g.writeRouter(stringBuilder, member.router, clientRouterTypeName(typeName, member), currentPath+member.slug)
+		}
+	}
+}
+
+// writes the method that calls a procedure. Queries and inputs that are any are left out of the parameters,
+// and the dynamic slugs of the path that have no matching query field become string parameters
+func (g *goClientGenerator) writeProcedure(stringBuilder *strings.Builder, typeName, methodName string, procedure procedureModel) {
+	queryType, hasQuery := g.modelExpr(procedure.query), procedure.query != nil
+	inputType, hasInput := g.modelExpr(procedure.input), procedure.input != nil
+	outputType := g.modelExpr(procedure.output)
+
+	params := []string{"ctx context.Context"}
+	if hasQuery {
+		params = append(params, "query "+queryType)
+	}
+	if hasInput {
+		params = append(params, "input "+inputType)
+	}
+	var slugParams []string
+	for _, slugName := range procedure.slugs {
+		param := goParamName(slugName) + "Slug"
+		params = append(params, param+" string")
+		slugParams = append(slugParams, fmt.Sprintf("%q: %s", slugName, param))
+	}
+
+	address := strconv.Quote(procedure.path)
+	if len(slugParams) > 0 {
+		address = fmt.Sprintf("bluerpc.FillSlugs(%s, map[string]string{%s})", address, strings.Join(slugParams, ", "))
+	}
+	queryArg, inputArg := "nil", "nil"
+	if hasQuery {
+		queryArg = "query"
+	}
+	if hasInput {
+		inputArg = "input"
+	}
+
+	var returnType, call string
+	switch procedure.method {
+	case MUTATION:
+		returnType = fmt.Sprintf("*bluerpc.Res[%s]", outputType)
+		call = fmt.Sprintf("bluerpc.CallMutation[%s, %s, %s](ctx, c.rpc, %s, %s, %s)", queryType, inputType, outputType, address, queryArg, inputArg)
+		if httpMethod := procedure.httpMethod; httpMethod != http.MethodPost {
+			call = fmt.Sprintf("bluerpc.CallMutationWithMethod[%s, %s, %s](ctx, c.rpc, %q, %s, %s, %s)", queryType, inputType, outputType, httpMethod, address, queryArg, inputArg)
+		}
+	case SUBSCRIPTION:
+		returnType = fmt.Sprintf("*bluerpc.ClientSubscription[%s]", outputType)
+		call = fmt.Sprintf("bluerpc.CallSubscription[%s, %s](ctx, c.rpc, %s, %s)", queryType, outputType, address, queryArg)
+	default:
+		returnType = fmt.Sprintf("*bluerpc.Res[%s]", outputType)
+		call = fmt.Sprintf("bluerpc.CallQuery[%s, %s](ctx, c.rpc, %s, %s)", queryType, outputType, address, queryArg)
+	}
+
+	stringBuilder.WriteString(fmt.Sprintf("// %s calls the %s at %s\n", methodName, procedure.method, procedure.path))
+	for _, line := range goDocLines(procedure.docs) {
+		stringBuilder.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	stringBuilder.WriteString(fmt.Sprintf("func (c *%s) %s(%s) (%s, error) {\n\treturn %s\n}\n\n", typeName, methodName, strings.Join(params, ", "), returnType, call))
+}
+
+// reports if the query struct has a field that is read under the given name (see queryParser)
+func queryHasField(t reflect.Type, name string) bool {
+	if t == nil || t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		queryKey := field.Tag.Get("paramName")
+		if queryKey == "" {
+			queryKey = field.Name
+		}
+		if field.IsExported() && queryKey == name {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the go expression of the type that a model was built from, any when the model is nil
+func (g *goClientGenerator) modelExpr(model *typeModel) string {
+	if model == nil {
+		return "any"
+	}
+	return g.typeExpr(model.goType)
+}
+
+// returns the go expression of a type as it is written in the generated file
+func (g *goClientGenerator) typeExpr(t reflect.Type) string {
+	if t == nil {
+		return "any"
+	}
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		if isImportableType(t) {
+			return g.importAlias(t.PkgPath()) + "." + t.Name()
+		}
+		return g.declare(t)
+	}
+	return g.underlyingExpr(t)
+}
+
+// returns the go expression of the structure of a type, without its name
+func (g *goClientGenerator) underlyingExpr(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + g.typeExpr(t.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeExpr(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), g.typeExpr(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", g.typeExpr(t.Key()), g.typeExpr(t.Elem()))
+	case reflect.Struct:
+		return g.structExpr(t)
+	case reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return "any"
+	default:
+		return t.Kind().String()
+	}
+}
+
+func (g *goClientGenerator) structExpr(t reflect.Type) string {
+	stringBuilder := strings.Builder{}
+	stringBuilder.WriteString("struct {\n")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		// unexported fields are never put on the wire, except for the fields of embedded structs that are promoted
+		if !field.IsExported() && !(field.Anonymous && fieldType.Kind() == reflect.Struct) {
+			continue
+		}
+		for _, line := range strings.Split(field.Tag.Get("doc"), "\n") {
+			if line != "" {
+				stringBuilder.WriteString("\t// " + line + "\n")
+			}
+		}
+		if field.Anonymous && fieldType.Name() != "" {
+			stringBuilder.WriteString("\t" + g.typeExpr(field.Type))
+		} else {
+			stringBuilder.WriteString(fmt.Sprintf("\t%s %s", field.Name, g.typeExpr(field.Type)))
+		}
+		if field.Tag != "" {
+			tag := string(field.Tag)
+			if strings.Contains(tag, "`") {
+				tag = strconv.Quote(tag)
+			} else {
+				tag = "`" + tag + "`"
+			}
+			stringBuilder.WriteString(" " + tag)
+		}
+		stringBuilder.WriteString("\n")
+	}
+	stringBuilder.WriteString("}")
+	return stringBuilder.String()
+}
+
+// copies a type that can't be imported into the generated file. Types that encode themselves can't be copied, they are received as raw json or as text
+func (g *goClientGenerator) declare(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	switch wireEncodingOf(t) {
+	case encodedAsRawJSON:
+		return g.importAlias("encoding/json") + ".RawMessage"
+	case encodedAsText:
+		return "string"
+	}
+
+	baseName := toPascalCase(tsTypeName(t))
+	if baseName == "" || baseName[0] < 'A' || baseName[0] > 'Z' {
+		baseName = "T" + baseName
+	}
+	name := baseName
+	for i := 2; g.takenNames[name]; i++ {
+		name = baseName + strconv.Itoa(i)
+	}
+	// the name is reserved before the type is expanded so that recursive types refer to themselves
+	g.names[t] = name
+	g.takenNames[name] = true
+
+	declaration := fmt.Sprintf("type %s %s", name, g.underlyingExpr(t))
+	g.declarations = append(g.declarations, declaration)
+	return name
+}
+
+// returns the alias of an imported package, importing it on the first use
+func (g *goClientGenerator) importAlias(importPath string) string {
+	if alias, ok := g.imports[importPath]; ok {
+		return alias
+	}
+	base := path.Base(importPath)
+	// major version suffixes (/v2) are not the name of the package
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(importPath))
+	}
+	baseAlias := goPackageName(base)
+	alias := baseAlias
+	for i := 2; g.aliases[alias]; i++ {
+		alias = baseAlias + strconv.Itoa(i)
+	}
+	g.aliases[alias] = true
+	g.imports[importPath] = alias
+	return alias
+}
+
+// a named type can be imported by the generated client if it is exported, not generic and not part of package main
+func isImportableType(t reflect.Type) bool {
+	return t.PkgPath() != "main" && token.IsExported(t.Name()) && !strings.Contains(t.Name(), "[")
+}
+
+// turns a directory or a package path element into a valid package name. go-redis becomes goredis
+func goPackageName(name string) string {
+	name = strings.ToLower(nonIdentifierRegex.ReplaceAllString(name, ""))
+	if name == "" || !token.IsIdentifier(name) || token.IsKeyword(name) {
+		return "pkg" + name
+	}
+	return name
+}
+
+// turns a slug name into a lower camel case go parameter name
+func goParamName(name string) string {
+	name = toPascalCase(name)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return "slug" + name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func goInt64ModeName(mode Int64Mode) string {
+	switch mode {
+	case Int64AsString:
+		return "bluerpc.Int64AsString"
+	case Int64AsBigInt:
+		return "bluerpc.Int64AsBigInt"
+	default:
+		return strconv.Quote(string(mode))
+	}
+}
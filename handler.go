@@ -30,6 +30,11 @@ type Query[query any, output any] func(ctx *Ctx, query query) (*Res[output], err
 // Third is OUTPUT.
 type Mutation[query any, input any, output any] func(ctx *Ctx, query query, input input) (*Res[output], error)
 
+// First Generic argument is QUERY PARAMETERS.
+// Second is the OUTPUT of every event sent through the emitter.
+// The subscription stays open until the handler returns or the client disconnects (see Emitter.Done)
+type Subscription[query any, output any] func(ctx *Ctx, query query, emitter *Emitter[output]) error
+
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
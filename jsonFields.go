@@ -0,0 +1,210 @@
+package bluerpc
+
+import (
+	"reflect"
+	"strings"
+)
+
+// wireField is a struct field the way it is put on the wire: its json name, where it is located (fields of embedded structs are promoted) and its tag options
+type wireField struct {
+	name      string
+	index     []int
+	field     reflect.StructField
+	omitEmpty bool
+	asString  bool
+	tagged    bool
+	depth     int
+}
+
+// returns the fields of a struct the way encoding/json sees them.
+// The name comes from the json tag first, then from the paramName tag and then from the field name. Fields tagged with json:"-" are skipped
+// and the fields of embedded structs without a json name are promoted to the parent, with the same conflict rules as encoding/json (the shallowest field wins, then the tagged one)
+func wireFields(t reflect.Type) []wireField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []wireField
+	collectWireFields(t, nil, map[reflect.Type]bool{}, &fields)
+
+	byName := map[string][]wireField{}
+	for _, field := range fields {
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	result := make([]wireField, 0, len(fields))
+	for _, field := range fields {
+		if dominant, ok := dominantField(byName[field.name]); ok && sameIndex(dominant.index, field.index) {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+func collectWireFields(t reflect.Type, index []int, visiting map[reflect.Type]bool, fields *[]wireField) {
+	// embedded pointers can make a struct contain itself
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(jsonTag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous {
+			if !field.IsExported() && fieldType.Kind() != reflect.Struct {
+				continue
+			}
+			if name == "" && fieldType.Kind() == reflect.Struct {
+				collectWireFields(fieldType, fieldIndex, visiting, fields)
+				continue
+			}
+		} else if !field.IsExported() {
+			continue
+		}
+
+		tagged := name != ""
+		if name == "" {
+			name, _, _ = strings.Cut(field.Tag.Get("paramName"), ",")
+			tagged = name != ""
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		*fields = append(*fields, wireField{
+			name:      name,
+			index:     fieldIndex,
+			field:     field,
+			omitEmpty: hasTagOption(options, "omitempty"),
+			asString:  hasTagOption(options, "string") && isStringableKind(fieldType.Kind()),
+			tagged:    tagged,
+			depth:     len(index),
+		})
+	}
+}
+
+// picks the field that wins between fields that share the same name
+func dominantField(fields []wireField) (wireField, bool) {
+	if len(fields) == 1 {
+		return fields[0], true
+	}
+	minDepth := fields[0].depth
+	for _, field := range fields {
+		if field.depth < minDepth {
+			minDepth = field.depth
+		}
+	}
+	var shallowest []wireField
+	for _, field := range fields {
+		if field.depth == minDepth {
+			shallowest = append(shallowest, field)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	var tagged []wireField
+	for _, field := range shallowest {
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return wireField{}, false
+}
+
+func sameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasTagOption(options string, option string) bool {
+	for options != "" {
+		var current string
+		current, options, _ = strings.Cut(options, ",")
+		if current == option {
+			return true
+		}
+	}
+	return false
+}
+
+// the ",string" json option only applies to strings, numbers and booleans
+func isStringableKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// returns the value of a (possibly promoted) field. It returns false if one of the embedded pointers on the way is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	field, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	return field, true
+}
+
+// returns a settable (possibly promoted) field, allocating the nil embedded pointers on the way
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+	return v
+}
+
+// same as encoding/json, the values that omitempty leaves out
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
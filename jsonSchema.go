@@ -0,0 +1,362 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12), the same dialect that OpenAPI 3.1 uses
+type JSONSchema struct {
+	Schema string                 `json:"$schema,omitempty"`
+	Ref    string                 `json:"$ref,omitempty"`
+	Defs   map[string]*JSONSchema `json:"$defs,omitempty"`
+
+	Type            string `json:"type,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Format          string `json:"format,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	Enum            []any  `json:"enum,omitempty"`
+	Deprecated      bool   `json:"deprecated,omitempty"`
+
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+
+	MinLength        *int     `json:"minLength,omitempty"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	MinItems         *int     `json:"minItems,omitempty"`
+	MaxItems         *int     `json:"maxItems,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+}
+
+// jsonSchemaBuilder turns the models of go types into JSON Schemas that describe exactly what is put on the wire, following the same rules as the generated typescript.
+// Named types are written once in defs and referenced everywhere else through refPrefix
+type jsonSchemaBuilder struct {
+	app       *App
+	models    *typeModels
+	refPrefix string
+	defs      map[string]*JSONSchema
+	names     map[reflect.Type]string
+}
+
+func newJSONSchemaBuilder(app *App, refPrefix string) *jsonSchemaBuilder {
+	return &jsonSchemaBuilder{
+		app:       app,
+		models:    newTypeModels(app),
+		refPrefix: refPrefix,
+		defs:      map[string]*JSONSchema{},
+		names:     map[reflect.Type]string{},
+	}
+}
+
+func (b *jsonSchemaBuilder) int64Mode() Int64Mode {
+	if b.app == nil {
+		return Int64AsNumber
+	}
+	return b.app.config.Int64Mode
+}
+
+// reports if the int64 values of the model are put on the wire as json strings
+func (b *jsonSchemaBuilder) sendsString(model *typeModel) bool {
+	return model.kind == kindInt64 && b.int64Mode().sendsString(model.goType.Kind())
+}
+
+// returns the schema of a go type
+func (b *jsonSchemaBuilder) typeSchema(t reflect.Type) *JSONSchema {
+	return b.schema(b.models.model(t))
+}
+
+// returns the schema of a model
+func (b *jsonSchemaBuilder) schema(model *typeModel) *JSONSchema {
+	if model == nil {
+		return &JSONSchema{}
+	}
+	if model.cycle != nil {
+		return b.schema(model.cycle)
+	}
+	if model.override != "" {
+		return tsTypeToJSONSchema(model.override)
+	}
+	if model.kind == kindEnum {
+		return b.named(model.goType, func() *JSONSchema { return b.enumSchema(model.decl) })
+	}
+	switch model.encoding {
+	case encodedAsTime:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case encodedAsRawJSON:
+		return &JSONSchema{}
+	case encodedAsNumber:
+		return &JSONSchema{Type: "number"}
+	case encodedAsText:
+		return &JSONSchema{Type: "string"}
+	case encodedAsBytes:
+		return &JSONSchema{Type: "string", ContentEncoding: "base64"}
+	case encodedAsInt64:
+		if b.sendsString(model) {
+			return &JSONSchema{Type: "string", Pattern: "^-?[0-9]+$"}
+		}
+	}
+
+	switch model.kind {
+	case kindOptional:
+		return b.schema(model.elem)
+	case kindList, kindMap, kindObject:
+		if model.goType != nil && model.goType.Name() != "" {
+			return b.named(model.goType, func() *JSONSchema { return b.expand(model) })
+		}
+	}
+	return b.expand(model)
+}
+
+// writes the schema of a named type in defs the first time it is used and returns a reference to it
+func (b *jsonSchemaBuilder) named(t reflect.Type, expand func() *JSONSchema) *JSONSchema {
+	if name, ok := b.names[t]; ok {
+		return &JSONSchema{Ref: b.refPrefix + name}
+	}
+	name := b.uniqueName(t)
+	// the name is reserved before the type is expanded so that recursive types refer to their own definition
+	b.names[t] = name
+	b.defs[name] = &JSONSchema{}
+	b.defs[name] = expand()
+	return &JSONSchema{Ref: b.refPrefix + name}
+}
+
+// same naming as the typescript declarations (see tsTypeRegistry.uniqueName)
+func (b *jsonSchemaBuilder) uniqueName(t reflect.Type) string {
+	name := tsTypeName(t)
+	if _, taken := b.defs[name]; !taken {
+		return name
+	}
+	qualified := toPascalCase(path.Base(t.PkgPath())) + name
+	candidate := qualified
+	for i := 2; ; i++ {
+		if _, taken := b.defs[candidate]; !taken {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", qualified, i)
+	}
+}
+
+func (b *jsonSchemaBuilder) expand(model *typeModel) *JSONSchema {
+	switch model.kind {
+	case kindString:
+		return &JSONSchema{Type: "string"}
+	case kindInt, kindInt64:
+		return &JSONSchema{Type: "integer"}
+	case kindFloat:
+		return &JSONSchema{Type: "number"}
+	case kindBool:
+		return &JSONSchema{Type: "boolean"}
+	case kindList:
+		return &JSONSchema{Type: "array", Items: b.schema(model.elem)}
+	case kindMap:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schema(model.elem)}
+	case kindObject:
+		return b.object(model.decl)
+	}
+	return &JSONSchema{}
+}
+
+// returns the schema of a struct with the same fields as tsTypeRegistry.object
+func (b *jsonSchemaBuilder) object(decl *typeDecl) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+	for _, field := range decl.fields {
+		schema.Properties[field.name] = b.fieldSchema(field)
+		if field.required {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	return schema
+}
+
+// returns the schema of the query parameters with the same fields as tsTypeRegistry.queryObject. The query can be nil.
+// The fields that are dynamic slugs are returned apart, they are part of the path
+func (b *jsonSchemaBuilder) queryObject(query *typeModel, dynamicSlugNames []string) (*JSONSchema, map[string]*JSONSchema) {
+	schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+	slugs := map[string]*JSONSchema{}
+	if query != nil {
+		for _, field := range query.decl.fields {
+			if field.slug {
+				slugs[field.name] = b.fieldSchema(field)
+				continue
+			}
+			schema.Properties[field.name] = b.fieldSchema(field)
+			if field.required {
+				schema.Required = append(schema.Required, field.name)
+			}
+		}
+	}
+	for _, slugName := range dynamicSlugNames {
+		if _, ok := slugs[slugName]; !ok {
+			slugs[slugName] = &JSONSchema{Type: "string"}
+		}
+	}
+	return schema, slugs
+}
+
+func (b *jsonSchemaBuilder) fieldSchema(field fieldModel) *JSONSchema {
+	schema, ok := b.enumTagSchema(field)
+	if !ok {
+		schema = b.schema(field.model)
+	}
+	return applyFieldDoc(applyValidateRules(schema, field.validate), field.doc)
+}
+
+func (b *jsonSchemaBuilder) enumSchema(decl *typeDecl) *JSONSchema {
+	schema := b.expand(&typeModel{kind: decl.baseKind})
+	if b.int64Mode().sendsString(decl.goType.Kind()) {
+		schema = &JSONSchema{Type: "string"}
+	}
+	for _, value := range decl.values {
+		schema.Enum = append(schema.Enum, b.wireValue(reflect.ValueOf(value)))
+	}
+	return schema
+}
+
+// returns the schema of a field that lists its values in an enum tag
+func (b *jsonSchemaBuilder) enumTagSchema(field fieldModel) (*JSONSchema, bool) {
+	if field.enum == nil {
+		return nil, false
+	}
+	valueModel, isList := enumTagValueModel(field.model)
+	schema := b.expand(valueModel)
+	if b.sendsString(valueModel) {
+		schema = &JSONSchema{Type: "string"}
+	}
+	for _, text := range field.enum {
+		switch {
+		case schema.Type == "string":
+			schema.Enum = append(schema.Enum, text)
+		case schema.Type == "boolean":
+			value, _ := strconv.ParseBool(text)
+			schema.Enum = append(schema.Enum, value)
+		default:
+			schema.Enum = append(schema.Enum, json.Number(text))
+		}
+	}
+	if isList {
+		return &JSONSchema{Type: "array", Items: schema}, true
+	}
+	return schema, true
+}
+
+// returns the value the way it is put on the wire
+func (b *jsonSchemaBuilder) wireValue(value reflect.Value) any {
+	if b.int64Mode().sendsString(value.Kind()) {
+		return enumValueString(value)
+	}
+	encoded, err := json.Marshal(value.Interface())
+	if err != nil {
+		return enumValueString(value)
+	}
+	var decoded any
+	json.Unmarshal(encoded, &decoded)
+	return decoded
+}
+
+// turns a value of a oneof rule into the json value of the given schema type
+func oneofValue(schemaType string, value string) any {
+	switch schemaType {
+	case "integer", "number":
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case "boolean":
+		if boolean, err := strconv.ParseBool(value); err == nil {
+			return boolean
+		}
+	}
+	return value
+}
+
+// returns the schema of a type set with OverrideTSType. Only the basic typescript types can be translated, anything else accepts every value
+func tsTypeToJSONSchema(tsType string) *JSONSchema {
+	switch tsType {
+	case "string", "number", "boolean":
+		return &JSONSchema{Type: tsType}
+	case "bigint":
+		return &JSONSchema{Type: "string", Pattern: "^-?[0-9]+$"}
+	}
+	return &JSONSchema{}
+}
+
+// describes the schema of a field with its doc tag. The schema is copied, a reference to a named type is shared by every field of that type
+func applyFieldDoc(schema *JSONSchema, doc string) *JSONSchema {
+	if doc == "" {
+		return schema
+	}
+	described := *schema
+	described.Description = doc
+	return &described
+}
+
+// translates the validate tag of a field into the schema, with the same rules as the zod schemas (see zodValidateRules).
+// The rules of a schema that is a reference are not applied to the referenced definition
+func applyValidateRules(schema *JSONSchema, rules []ValidationRule) *JSONSchema {
+	if len(rules) == 0 || schema.Ref != "" {
+		return schema
+	}
+	for _, rule := range rules {
+		name, param := rule.Name, rule.Param
+		if name == "dive" {
+			break
+		}
+		if strings.Contains(name+param, "|") {
+			continue
+		}
+		number, paramErr := strconv.ParseFloat(param, 64)
+		isNumeric := param != "" && paramErr == nil
+		integer := int(number)
+
+		switch {
+		case name == "oneof" && schema.Type != "array" && schema.Type != "object":
+			schema.Enum = nil
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, oneofValue(schema.Type, value))
+			}
+		case name == "email" && schema.Type == "string":
+			schema.Format = "email"
+		case name == "url" && schema.Type == "string":
+			schema.Format = "uri"
+		case name == "uuid" && schema.Type == "string":
+			schema.Format = "uuid"
+		case !isNumeric:
+			continue
+		case schema.Type == "string" && (name == "min" || name == "max" || name == "len"):
+			if name != "max" {
+				schema.MinLength = &integer
+			}
+			if name != "min" {
+				schema.MaxLength = &integer
+			}
+		case schema.Type == "array" && (name == "min" || name == "max" || name == "len"):
+			if name != "max" {
+				schema.MinItems = &integer
+			}
+			if name != "min" {
+				schema.MaxItems = &integer
+			}
+		case schema.Type == "integer" || schema.Type == "number":
+			switch name {
+			case "min", "gte":
+				schema.Minimum = &number
+			case "max", "lte":
+				schema.Maximum = &number
+			case "gt":
+				schema.ExclusiveMinimum = &number
+			case "lt":
+				schema.ExclusiveMaximum = &number
+			}
+		}
+	}
+	return schema
+}
@@ -0,0 +1,485 @@
+package bluerpc
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the kotlin hard keywords, names that take one of them are escaped with backticks
+var kotlinKeywords = map[string]bool{"as": true, "break": true, "class": true, "continue": true, "do": true, "else": true, "false": true, "for": true, "fun": true,
+	"if": true, "in": true, "interface": true, "is": true, "null": true, "object": true, "package": true, "return": true, "super": true, "this": true, "throw": true,
+	"true": true, "try": true, "typealias": true, "typeof": true, "val": true, "var": true, "when": true, "while": true}
+
+// kotlinClientGenerator writes the kotlin client of an app from the models of its types. Every struct becomes a kotlinx.serialization data class,
+// every registered enum an enum class and every router a class with a suspend function per procedure, the same tree as the go client
+type kotlinClientGenerator struct {
+	app    *App
+	models *typeModels
+}
+
+// returns the kotlin client that is written at outputPath, in the package of the config or in the one named after its directory
+func (a *App) kotlinClientFile(outputPath string) ([]byte, error) {
+	packageName := a.config.KotlinClientPackage
+	if packageName == "" {
+		packageName = goPackageName(filepath.Base(filepath.Dir(outputPath)))
+	}
+	return a.GenerateKotlinClient(packageName)
+}
+
+// GenerateKotlinClient returns the source of a kotlin client for every procedure of the app, in the package packageName.
+// It needs ktor-client and kotlinx-serialization-json. The client mirrors the router tree : the query attached at /users/{id} is called with client.users.byId(query)
+func (a *App) GenerateKotlinClient(packageName string) ([]byte, error) {
+	for _, part := range strings.Split(packageName, ".") {
+		if !isTSIdentifier(part) || strings.Contains(part, "$") {
+			return nil, &Error{Code: 500, Message: fmt.Sprintf("%s is not a valid kotlin package name", packageName)}
+		}
+	}
+	g := &kotlinClientGenerator{app: a, models: newTypeModels(a, "RpcClient", "RpcResponse", "RpcException", "RpcInt64", "Int64AsStringSerializer", "Client",
+		"HttpClient", "HttpMethod", "Flow", "Json", "JsonElement", "JsonObject", "KSerializer", "Serializable", "SerialName", "Long", "Int", "Double", "String", "Boolean")}
+
+	routers := &strings.Builder{}
+	reserveClientRouterNames(a.startRoute, "Client", g.models.takenNames)
+	g.writeRouter(routers, a.startRoute, "Client", "")
+
+	source := &strings.Builder{}
+	source.WriteString(generatedHeader("//"))
+	source.WriteString("package " + kotlinPackageName(packageName) + "\n")
+	source.WriteString(kotlinRuntime)
+	if a.config.Int64Mode.sendsString(reflect.Int64) {
+		source.WriteString("\n// int64 and uint64 values are sent as json strings\ntypealias RpcInt64 = @Serializable(with = Int64AsStringSerializer::class) Long\n")
+	} else {
+		source.WriteString("\ntypealias RpcInt64 = Long\n")
+	}
+	for _, decl := range g.models.decls {
+		if decl.goType.Kind() == reflect.Struct {
+			g.writeDataClass(source, decl)
+		} else {
+			g.writeEnum(source, decl)
+		}
+	}
+	source.WriteString(routers.String())
+	return []byte(source.String()), nil
+}
+
+func (g *kotlinClientGenerator) writeRouter(stringBuilder *strings.Builder, router *Router, className string, currentPath string) {
+	members := routerMembers(router)
+
+	location := currentPath
+	if location == "" {
+		location = "/"
+	}
+	stringBuilder.WriteString(fmt.Sprintf("\n/** %s calls the procedures under %s */\n", className, location))
+	stringBuilder.WriteString(fmt.Sprintf("class %s(val rpc: RpcClient) {\n", className))
+	if currentPath == "" {
+		baseURL := ""
+		if g.app.config.ServerURL != "" {
+			baseURL = " = " + kotlinString(g.app.config.ServerURL)
+		}
+		stringBuilder.WriteString(fmt.Sprintf("    constructor(baseUrl: String%s, http: HttpClient = HttpClient(), headers: Map<String, String> = emptyMap()) : this(RpcClient(baseUrl, http, headers))\n", baseURL))
+	}
+	for _, member := range members {
+		if member.router != nil {
+			stringBuilder.WriteString(fmt.Sprintf("    val %s = %s(rpc)\n", kotlinName(lowerCamelName(member.name)), clientRouterTypeName(className, member)))
+		}
+	}
+	// the properties and the functions are separated by a blank line
+	separate := currentPath == ""
+	for _, member := range members {
+		if member.router != nil {
+			separate = true
+		}
+	}
+	for _, member := range members {
+		if member.proc != nil {
+			if separate {
+				stringBuilder.WriteString("\n")
+			}
+			separate = true
+			g.writeProcedure(stringBuilder, lowerCamelName(member.name), g.models.procedure(member.proc, currentPath+member.slug))
+		}
+	}
+	stringBuilder.WriteString("}\n")
+	for _, member := range members {
+		if member.router != nil {
+			g.writeRouter(stringBuilder, member.router, clientRouterTypeName(className, member), currentPath+member.slug)
+		}
+	}
+}
+
+// writes the function that calls a procedure. Queries and inputs that are any are left out of the parameters,
+// and the dynamic slugs of the path that have no matching query field become string parameters
+func (g *kotlinClientGenerator) writeProcedure(stringBuilder *strings.Builder, methodName string, proc procedureModel) {
+	var params []string
+	args := []string{kotlinString(proc.path), g.serializer(proc.output)}
+	if proc.query != nil {
+		params = append(params, "query: "+g.typeExpr(proc.query))
+		args = append(args, fmt.Sprintf("query = rpc.encodeQuery(%s, query)", g.serializer(proc.query)))
+	}
+	if proc.input != nil {
+		params = append(params, "input: "+g.typeExpr(proc.input))
+		args = append(args, fmt.Sprintf("input = rpc.json.encodeToString(%s, input)", g.serializer(proc.input)))
+	}
+	var slugArgs []string
+	for _, slugName := range proc.slugs {
+		param := lowerCamelName(goParamName(slugName)) + "Slug"
+		params = append(params, param+": String")
+		slugArgs = append(slugArgs, fmt.Sprintf("%s to %s", kotlinString(slugName), param))
+	}
+	if len(slugArgs) > 0 {
+		args = append(args, "slugs = mapOf("+strings.Join(slugArgs, ", ")+")")
+	}
+	params = append(params, "headers: Map<String, String> = emptyMap()")
+	args = append(args, "headers = headers")
+
+	docLines := clientDocLines(proc.method, proc.path, proc.docs)
+	for _, procErr := range proc.errors {
+		if procErr.data != nil {
+			docLines = append(docLines, fmt.Sprintf("@throws RpcException its data is a %s when its status is %d", g.typeExpr(procErr.data), procErr.code))
+		}
+	}
+	stringBuilder.WriteString(indentLines(jsDoc(docLines...), "    ") + "\n")
+	if proc.docs.deprecated {
+		stringBuilder.WriteString(fmt.Sprintf("    @Deprecated(%s)\n", kotlinString(strings.TrimSpace(proc.path+" is deprecated "+proc.docs.deprecatedMessage()))))
+	}
+	outputType := g.typeExpr(proc.output)
+	switch proc.method {
+	case SUBSCRIPTION:
+		stringBuilder.WriteString(fmt.Sprintf("    fun %s(%s): Flow<%s> =\n", kotlinName(methodName), strings.Join(params, ", "), outputType))
+		stringBuilder.WriteString(fmt.Sprintf("        rpc.subscribe(%s)\n", strings.Join(args, ", ")))
+	default:
+		// HttpMethod.Get, HttpMethod.Post, HttpMethod.Put...
+		httpMethod := "HttpMethod." + proc.httpMethod[:1] + strings.ToLower(proc.httpMethod[1:])
+		stringBuilder.WriteString(fmt.Sprintf("    suspend fun %s(%s): RpcResponse<%s> =\n", kotlinName(methodName), strings.Join(params, ", "), outputType))
+		stringBuilder.WriteString(fmt.Sprintf("        rpc.call(%s, %s)\n", httpMethod, strings.Join(args, ", ")))
+	}
+}
+
+// returns the kotlin type of a model
+func (g *kotlinClientGenerator) typeExpr(model *typeModel) string {
+	if model == nil {
+		return "JsonElement"
+	}
+	switch model.kind {
+	case kindString, kindTime:
+		// times are kept as their RFC 3339 strings, kotlinx.serialization has no serializer for them
+		return "String"
+	case kindBool:
+		return "Boolean"
+	case kindInt:
+		return "Long"
+	case kindInt64:
+		return "RpcInt64"
+	case kindFloat:
+		return "Double"
+	case kindOptional:
+		return g.typeExpr(model.elem) + "?"
+	case kindList:
+		return "List<" + g.typeExpr(model.elem) + ">"
+	case kindMap:
+		return "Map<String, " + g.typeExpr(model.elem) + ">"
+	case kindObject:
+		return model.decl.name
+	case kindEnum:
+		if model.decl.baseKind != kindString {
+			return g.typeExpr(&typeModel{kind: model.decl.baseKind})
+		}
+		return model.decl.name
+	default:
+		return "JsonElement"
+	}
+}
+
+// returns the expression of the serializer of a model. It is written out rather than inferred so that the int64 values keep the serializer of their type alias
+func (g *kotlinClientGenerator) serializer(model *typeModel) string {
+	if model == nil {
+		return "JsonElement.serializer()"
+	}
+	switch model.kind {
+	case kindInt64:
+		if g.app.config.Int64Mode.sendsString(reflect.Int64) {
+			return "Int64AsStringSerializer"
+		}
+		return "Long.serializer()"
+	case kindOptional:
+		return g.serializer(model.elem) + ".nullable"
+	case kindList:
+		return "ListSerializer(" + g.serializer(model.elem) + ")"
+	case kindMap:
+		return "MapSerializer(String.serializer(), " + g.serializer(model.elem) + ")"
+	default:
+		return g.typeExpr(model) + ".serializer()"
+	}
+}
+
+// writes the data class of a struct, the fields that are not required default to null
+func (g *kotlinClientGenerator) writeDataClass(source *strings.Builder, decl *typeDecl) {
+	source.WriteString("\n@Serializable\n")
+	if len(decl.fields) == 0 {
+		// a data class needs at least one property
+		source.WriteString(fmt.Sprintf("class %s\n", decl.name))
+		return
+	}
+	source.WriteString(fmt.Sprintf("data class %s(\n", decl.name))
+	taken := map[string]bool{}
+	for _, field := range decl.fields {
+		name := lowerCamelName(field.name)
+		if name == "" || (name[0] >= '0' && name[0] <= '9') {
+			name = "field" + toPascalCase(field.name)
+		}
+		for i := 2; taken[name]; i++ {
+			name = lowerCamelName(field.name) + strconv.Itoa(i)
+		}
+		taken[name] = true
+
+		fieldType := g.typeExpr(field.model)
+		defaultValue := ""
+		if !field.required {
+			if !strings.HasSuffix(fieldType, "?") {
+				fieldType += "?"
+			}
+			defaultValue = " = null"
+		}
+		if field.doc != "" {
+			source.WriteString(indentLines(jsDoc(strings.Split(field.doc, "\n")...), "    ") + "\n")
+		}
+		source.WriteString(fmt.Sprintf("    @SerialName(%s) val %s: %s%s,\n", kotlinString(field.name), kotlinName(name), fieldType, defaultValue))
+	}
+	source.WriteString(")\n")
+}
+
+// writes the enum class of a registered string enum, enums of other kinds are written as their base type
+func (g *kotlinClientGenerator) writeEnum(source *strings.Builder, decl *typeDecl) {
+	if decl.baseKind != kindString {
+		return
+	}
+	source.WriteString(fmt.Sprintf("\n@Serializable\nenum class %s {\n", decl.name))
+	taken := map[string]bool{}
+	for _, value := range decl.values {
+		name := strings.ToUpper(pySnakeName(fmt.Sprint(value)))
+		if name == "" || (name[0] >= '0' && name[0] <= '9') {
+			name = "VALUE_" + name
+		}
+		for i := 2; taken[name]; i++ {
+			name = strings.ToUpper(pySnakeName(fmt.Sprint(value))) + "_" + strconv.Itoa(i)
+		}
+		taken[name] = true
+		source.WriteString(fmt.Sprintf("    @SerialName(%s) %s,\n", kotlinString(fmt.Sprint(value)), name))
+	}
+	source.WriteString("}\n")
+}
+
+// escapes the kotlin keywords
+func kotlinName(name string) string {
+	if kotlinKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// escapes the keywords of the parts of a package name
+func kotlinPackageName(packageName string) string {
+	parts := strings.Split(packageName, ".")
+	for i, part := range parts {
+		parts[i] = kotlinName(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quotes a kotlin string, the $ of the templates included
+func kotlinString(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), "$", "\\$")
+}
+
+// indents every line of a comment
+func indentLines(text string, indentation string) string {
+	return indentation + strings.ReplaceAll(text, "\n", "\n"+indentation)
+}
+
+// the part of the kotlin client that does not depend on the procedures : the responses, the errors and the calls
+const kotlinRuntime = `
+import io.ktor.client.HttpClient
+import io.ktor.client.request.header
+import io.ktor.client.request.parameter
+import io.ktor.client.request.prepareGet
+import io.ktor.client.request.request
+import io.ktor.client.request.setBody
+import io.ktor.client.statement.bodyAsChannel
+import io.ktor.client.statement.bodyAsText
+import io.ktor.http.ContentType
+import io.ktor.http.Headers
+import io.ktor.http.HttpHeaders
+import io.ktor.http.HttpMethod
+import io.ktor.http.contentType
+import io.ktor.utils.io.readUTF8Line
+import java.net.URLEncoder
+import kotlinx.coroutines.flow.Flow
+import kotlinx.coroutines.flow.flow
+import kotlinx.serialization.KSerializer
+import kotlinx.serialization.SerialName
+import kotlinx.serialization.Serializable
+import kotlinx.serialization.SerializationException
+import kotlinx.serialization.builtins.ListSerializer
+import kotlinx.serialization.builtins.MapSerializer
+import kotlinx.serialization.builtins.nullable
+import kotlinx.serialization.builtins.serializer
+import kotlinx.serialization.descriptors.PrimitiveKind
+import kotlinx.serialization.descriptors.PrimitiveSerialDescriptor
+import kotlinx.serialization.encoding.Decoder
+import kotlinx.serialization.encoding.Encoder
+import kotlinx.serialization.json.Json
+import kotlinx.serialization.json.JsonArray
+import kotlinx.serialization.json.JsonDecoder
+import kotlinx.serialization.json.JsonElement
+import kotlinx.serialization.json.JsonNull
+import kotlinx.serialization.json.JsonObject
+import kotlinx.serialization.json.JsonPrimitive
+import kotlinx.serialization.json.jsonObject
+import kotlinx.serialization.json.jsonPrimitive
+
+data class RpcResponse<T>(val body: T, val status: Int, val headers: Headers)
+
+/** An error response of the server. Decode the data of the errors that the procedure declares with data(serializer) */
+class RpcException(val status: Int, override val message: String, val data: JsonElement?) : Exception(message) {
+    fun <T> data(serializer: KSerializer<T>, json: Json = rpcJson): T? = data?.let { json.decodeFromJsonElement(serializer, it) }
+}
+
+/** Reads int64 values from json strings or numbers and writes them as strings so that they keep their precision */
+object Int64AsStringSerializer : KSerializer<Long> {
+    override val descriptor = PrimitiveSerialDescriptor("RpcInt64", PrimitiveKind.STRING)
+
+    override fun serialize(encoder: Encoder, value: Long) = encoder.encodeString(value.toString())
+
+    override fun deserialize(decoder: Decoder): Long {
+        val input = decoder as? JsonDecoder ?: return decoder.decodeString().toLong()
+        return input.decodeJsonElement().jsonPrimitive.content.toLong()
+    }
+}
+
+val rpcJson = Json {
+    ignoreUnknownKeys = true
+    explicitNulls = false
+}
+
+/** Sends the calls of the client. The headers are sent with every call (an Authorization header for example) */
+class RpcClient(
+    baseUrl: String,
+    val http: HttpClient = HttpClient(),
+    val headers: Map<String, String> = emptyMap(),
+    val json: Json = rpcJson,
+) {
+    val baseUrl = baseUrl.trimEnd('/')
+
+    fun <T> encodeQuery(serializer: KSerializer<T>, query: T): JsonObject = json.encodeToJsonElement(serializer, query).jsonObject
+
+    suspend fun <T> call(
+        method: HttpMethod,
+        path: String,
+        output: KSerializer<T>,
+        query: JsonObject? = null,
+        input: String? = null,
+        slugs: Map<String, String> = emptyMap(),
+        headers: Map<String, String> = emptyMap(),
+    ): RpcResponse<T> {
+        val (url, parameters) = address(path, query, slugs)
+        val response = http.request(url) {
+            this.method = method
+            parameters.forEach { (key, value) -> parameter(key, value) }
+            (this@RpcClient.headers + headers).forEach { (key, value) -> header(key, value) }
+            if (input != null) {
+                contentType(ContentType.Application.Json)
+                setBody(input)
+            }
+        }
+        val text = response.bodyAsText()
+        if (response.status.value >= 400) throw error(response.status.value, text)
+        return RpcResponse(decode(output, text), response.status.value, response.headers)
+    }
+
+    fun <T> subscribe(
+        path: String,
+        output: KSerializer<T>,
+        query: JsonObject? = null,
+        slugs: Map<String, String> = emptyMap(),
+        headers: Map<String, String> = emptyMap(),
+    ): Flow<T> = flow {
+        val (url, parameters) = address(path, query, slugs)
+        http.prepareGet(url) {
+            parameters.forEach { (key, value) -> parameter(key, value) }
+            (this@RpcClient.headers + headers).forEach { (key, value) -> header(key, value) }
+            header(HttpHeaders.Accept, "text/event-stream")
+        }.execute { response ->
+            if (response.status.value >= 400) throw error(response.status.value, response.bodyAsText())
+            val channel = response.bodyAsChannel()
+            var name = "message"
+            val data = mutableListOf<String>()
+            while (true) {
+                val line = channel.readUTF8Line() ?: break
+                when {
+                    line.startsWith("event:") -> name = line.removePrefix("event:").trim()
+                    line.startsWith("data:") -> data.add(line.removePrefix("data:").removePrefix(" "))
+                    line.isEmpty() && data.isNotEmpty() -> {
+                        val payload = data.joinToString("\n")
+                        if (name == "error") throw error(500, payload)
+                        emit(decode(output, payload))
+                        name = "message"
+                        data.clear()
+                    }
+                }
+            }
+        }
+    }
+
+    // the fields of the query fill the dynamic slugs that share their name, every other field becomes a query parameter
+    private fun address(path: String, query: JsonObject?, slugs: Map<String, String>): Pair<String, List<Pair<String, String>>> {
+        val values = slugs.toMutableMap()
+        val parameters = mutableListOf<Pair<String, String>>()
+        val segments = path.split("/")
+        val slugNames = segments.filter { it.startsWith("{") && it.endsWith("}") }.map { it.removeSurrounding("{", "}").removeSuffix("...") }
+        query?.keys?.sorted()?.forEach { key ->
+            val value = queryValue(query.getValue(key)) ?: return@forEach
+            if (key in slugNames) values[key] = value else parameters.add(key to value)
+        }
+        val filled = segments.joinToString("/") { segment ->
+            if (!segment.startsWith("{") || !segment.endsWith("}")) return@joinToString segment
+            val name = segment.removeSurrounding("{", "}")
+            if (name == "$") return@joinToString ""
+            val slugName = name.removeSuffix("...")
+            val value = values[slugName] ?: throw IllegalArgumentException("no value was given for the $slugName slug of $path")
+            // wildcards that span multiple segments keep their slashes
+            value.split("/").joinToString(if (name.endsWith("...")) "/" else "%2F") { URLEncoder.encode(it, "UTF-8").replace("+", "%20") }
+        }
+        return baseUrl + filled to parameters
+    }
+
+    private fun queryValue(value: JsonElement): String? = when (value) {
+        is JsonNull -> null
+        is JsonPrimitive -> value.content
+        is JsonArray -> value.mapNotNull { queryValue(it) }.joinToString(",")
+        is JsonObject -> null
+    }
+
+    private fun <T> decode(serializer: KSerializer<T>, text: String): T {
+        if (text.isEmpty()) return json.decodeFromJsonElement(serializer, JsonNull)
+        return try {
+            json.decodeFromString(serializer, text)
+        } catch (e: SerializationException) {
+            // bodies that are not json are plain text
+            json.decodeFromJsonElement(serializer, JsonPrimitive(text))
+        }
+    }
+
+    private fun error(status: Int, text: String): RpcException {
+        val body = try {
+            json.parseToJsonElement(text) as? JsonObject
+        } catch (e: SerializationException) {
+            null
+        }
+        val message = (body?.get("message") as? JsonPrimitive)?.content?.takeIf { it.isNotEmpty() } ?: text.trim()
+        val data = body?.get("data")?.takeIf { it !is JsonNull }
+        return RpcException(status, message, data)
+    }
+}
+`
@@ -14,9 +14,7 @@ func DefaultErrorMiddleware(ctx *Ctx) error {
 
 	if e, ok := err.(*Error); ok {
 
-		return ctx.status(e.Code).jSON(Map{
-			"message": e.Message,
-		})
+		return ctx.status(e.Code).jSON(e.body())
 	}
 	return ctx.status(500).jSON(Map{
 		"message": err.Error(),
@@ -26,7 +24,7 @@ func DefaultErrorMiddleware(ctx *Ctx) error {
 func createDefaultCorsOrigin(cors string) func(ctx *Ctx) error {
 	return func(ctx *Ctx) error {
 		ctx.httpW.Header().Set("Access-Control-Allow-Origin", cors)
-		ctx.httpW.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+		ctx.httpW.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 
 		return nil
 	}
@@ -6,7 +6,10 @@ import (
 	"strings"
 )
 
-func nodeToTS(stringBuilder *strings.Builder, router *Router, isLast bool, currentPath string) {
+func nodeToTS(stringBuilder *strings.Builder, types *tsTypeRegistry, router *Router, isLast bool, currentPath string) {
+	if types == nil {
+		types = newInlineTSTypeRegistry(nil)
+	}
 
 	stringBuilder.WriteString("{")
 
@@ -19,37 +22,32 @@ func nodeToTS(stringBuilder *strings.Builder, router *Router, isLast bool, curre
 
 			proc := router.procedures[slug]
 
-			//this string split handles the case where there this is a nested dynamic route, something like /:id/name
-			tsProcPath, err := splitStringOnSlash(slug)
-
-			if err != nil {
-				panic(err)
-			}
+			tsProcPath := tsProcedureKeys(slug)
 
 			for _, path := range tsProcPath {
-
-				path = strings.ReplaceAll(path, "/", "")
-				if path[0] == ':' {
-					path = path[1:]
-				}
 				stringBuilder.WriteString(fmt.Sprintf("[`%s`]:{", path))
 
 			}
 			if proc.method == STATIC {
 				continue
 			}
+			if doc := proc.docs.jsDoc(); doc != "" {
+				stringBuilder.WriteString(doc)
+			}
 			if proc.protected {
 				stringBuilder.WriteString("_")
 			}
+			procedure := types.models.procedure(proc, fullPath)
 			switch proc.method {
 			case QUERY:
 				stringBuilder.WriteString("query: async ")
-				query, output := proc.querySchema, proc.outputSchema
-				genTSFuncFromQuery(stringBuilder, query, output, fullPath)
+				genTSFuncFromQuery(stringBuilder, types, procedure)
 			case MUTATION:
 				stringBuilder.WriteString("mutation: async ")
-				query, input, output := proc.querySchema, proc.inputSchema, proc.outputSchema
-				genTSFuncFromMutation(stringBuilder, query, input, output, fullPath)
+				genTSFuncFromMutation(stringBuilder, types, procedure)
+			case SUBSCRIPTION:
+				stringBuilder.WriteString("subscribe: ")
+				genTSFuncFromSubscription(stringBuilder, types, procedure)
 
 			}
 
@@ -62,13 +60,9 @@ func nodeToTS(stringBuilder *strings.Builder, router *Router, isLast bool, curre
 	if router.routes != nil {
 		keys := getSortedKeys(router.routes)
 		for i, path := range keys {
-			tsObjectPath := strings.ReplaceAll(path, "/", "")
-			if tsObjectPath[0] == ':' {
-				tsObjectPath = tsObjectPath[1:]
-			}
-			stringBuilder.WriteString(fmt.Sprintf("[`%s`]:", tsObjectPath))
+			stringBuilder.WriteString(fmt.Sprintf("[`%s`]:", tsRouterKey(path)))
 
-			nodeToTS(stringBuilder, router.routes[path], i == len(keys)-1, currentPath+path)
+			nodeToTS(stringBuilder, types, router.routes[path], i == len(keys)-1, currentPath+path)
 		}
 
 	}
@@ -78,6 +72,29 @@ func nodeToTS(stringBuilder *strings.Builder, router *Router, isLast bool, curre
 		stringBuilder.WriteString(",")
 	}
 }
+
+// returns the keys of the nested objects that a procedure is written under in rpcAPI.
+// This string split handles the case where there this is a nested dynamic route, something like /:id/name
+func tsProcedureKeys(slug string) []string {
+	tsProcPath, err := splitStringOnSlash(slug)
+	if err != nil {
+		panic(err)
+	}
+	for i, path := range tsProcPath {
+		tsProcPath[i] = tsRouterKey(path)
+	}
+	return tsProcPath
+}
+
+// returns the key that a router is written under in rpcAPI
+func tsRouterKey(path string) string {
+	path = strings.ReplaceAll(path, "/", "")
+	if path != "" && path[0] == ':' {
+		path = path[1:]
+	}
+	return path
+}
+
 func getSortedKeys[values any](m map[string]values) []string {
 	keys := make([]string, 0, len(m))
 	for key := range m {
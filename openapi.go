@@ -0,0 +1,267 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIInfo is the info object of the generated OpenAPI document
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityScheme describes how an Authorizer authenticates its users in the generated OpenAPI document.
+// Type is one of http, apiKey, oauth2 or openIdConnect
+type SecurityScheme struct {
+	Type             string `json:"type"`
+	Description      string `json:"description,omitempty"`
+	Scheme           string `json:"scheme,omitempty"`
+	BearerFormat     string `json:"bearerFormat,omitempty"`
+	In               string `json:"in,omitempty"`
+	Name             string `json:"name,omitempty"`
+	OpenIdConnectUrl string `json:"openIdConnectUrl,omitempty"`
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIServer struct {
+	Url string `json:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*JSONSchema     `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationId string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *JSONSchema `json:"schema"`
+}
+
+// the security scheme of authorizers that do not set their own
+var defaultSecurityScheme = &SecurityScheme{Type: "http", Scheme: "bearer"}
+
+// OpenAPI returns the OpenAPI 3.1 document of every procedure of the app, encoded as json.
+// Queries and subscriptions are GET operations whose query parameters are the fields of their query struct, mutations are POST operations whose request body is their input.
+// Protected procedures require the security scheme of their Authorizer
+func (a *App) OpenAPI() ([]byte, error) {
+	info := a.config.OpenAPIInfo
+	if info.Title == "" {
+		info.Title = "bluerpc"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+
+	doc := &openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			SecuritySchemes: map[string]*SecurityScheme{},
+		},
+	}
+	if a.config.ServerURL != "" {
+		doc.Servers = []openAPIServer{{Url: a.config.ServerURL}}
+	}
+
+	schemas := newJSONSchemaBuilder(a, "#/components/schemas/")
+	a.addOpenAPIPaths(doc, schemas)
+	doc.Components.Schemas = schemas.defs
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func (a *App) addOpenAPIPaths(doc *openAPIDocument, schemas *jsonSchemaBuilder) {
+	// /users-list and /users/list get the same id, the ones that come after the first get a number
+	usedIds := map[string]bool{}
+	a.walkProcedures(func(fullPath string, proc *ProcedureInfo) {
+		openAPIPath := toOpenAPIPath(fullPath)
+		if doc.Paths[openAPIPath] == nil {
+			doc.Paths[openAPIPath] = map[string]*openAPIOperation{}
+		}
+		operation := a.openAPIOperation(doc, schemas, proc, fullPath)
+		operationId := operation.OperationId
+		for i := 2; usedIds[operationId]; i++ {
+			operationId = fmt.Sprintf("%s%d", operation.OperationId, i)
+		}
+		operation.OperationId = operationId
+		usedIds[operationId] = true
+
+		httpMethod := strings.ToLower(proc.httpMethod())
+		doc.Paths[openAPIPath][httpMethod] = operation
+	})
+}
+
+func (a *App) openAPIOperation(doc *openAPIDocument, schemas *jsonSchemaBuilder, proc *ProcedureInfo, fullPath string) *openAPIOperation {
+	operation := &openAPIOperation{
+		OperationId: string(proc.method) + toPascalCase(fullPath),
+		Summary:     proc.docs.summary,
+		Description: proc.docs.description,
+		Tags:        proc.docs.tags,
+		Deprecated:  proc.docs.deprecated,
+		Responses:   map[string]*openAPIResponse{},
+	}
+
+	procedure := schemas.models.procedure(proc, fullPath)
+
+	// path parameters first, then query parameters
+	dynamicSlugNames := findDynamicSlugs(fullPath)
+	query, slugs := schemas.queryObject(procedure.query, dynamicSlugNames)
+	for _, slugName := range dynamicSlugNames {
+		operation.Parameters = append(operation.Parameters, &openAPIParameter{Name: slugName, In: "path", Required: true, Schema: slugs[slugName]})
+	}
+	for _, name := range getSortedKeys(query.Properties) {
+		operation.Parameters = append(operation.Parameters, &openAPIParameter{Name: name, In: "query", Required: sliceStrContains(query.Required, name), Schema: query.Properties[name]})
+	}
+
+	if procedure.input != nil {
+		operation.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  map[string]*openAPIMediaType{ApplicationJSON: {Schema: schemas.schema(procedure.input)}},
+		}
+	}
+
+	contentType := ApplicationJSON
+	if proc.method == SUBSCRIPTION {
+		// every server sent event holds one output as its data
+		contentType = TextEventStream
+	}
+	success := &openAPIResponse{Description: "Success", Content: map[string]*openAPIMediaType{contentType: {Schema: schemas.schema(procedure.output)}}}
+	operation.Responses["200"] = success
+
+	errorResponse := &openAPIResponse{Description: "Error"}
+	if !a.config.DisableJSONOnlyErrors {
+		errorResponse.Content = map[string]*openAPIMediaType{ApplicationJSON: {Schema: schemas.typeSchema(reflect.TypeOf(ErrorResponse{}))}}
+	}
+	operation.Responses["default"] = errorResponse
+	for _, procErr := range procedure.errors {
+		operation.Responses[strconv.Itoa(procErr.code)] = declaredErrorResponse(schemas, procErr, errorResponse.Content != nil)
+	}
+
+	if proc.protected && proc.authorizer != nil {
+		name := doc.addSecurityScheme(proc.authorizer.securityScheme())
+		operation.Security = []map[string][]string{{name: {}}}
+		operation.Responses["401"] = &openAPIResponse{Description: "Unauthorized", Content: errorResponse.Content}
+	}
+	return operation
+}
+
+// documents an error declared with Throws. Its body is the message followed by the data of the error
+func declaredErrorResponse(schemas *jsonSchemaBuilder, procErr errorModel, isJSON bool) *openAPIResponse {
+	response := &openAPIResponse{Description: http.StatusText(procErr.code)}
+	if response.Description == "" {
+		response.Description = "Error"
+	}
+	if !isJSON {
+		return response
+	}
+	body := &JSONSchema{
+		Type:       "object",
+		Properties: map[string]*JSONSchema{"message": {Type: "string"}},
+		Required:   []string{"message"},
+	}
+	if procErr.data != nil {
+		body.Properties["data"] = schemas.schema(procErr.data)
+		body.Required = append(body.Required, "data")
+	}
+	response.Content = map[string]*openAPIMediaType{ApplicationJSON: {Schema: body}}
+	return response
+}
+
+// returns the name and the scheme that the authorizer is documented with
+func (auth *Authorizer) securityScheme() (string, *SecurityScheme) {
+	name, scheme := auth.SecuritySchemeName, auth.SecurityScheme
+	if scheme == nil {
+		scheme = defaultSecurityScheme
+	}
+	if name == "" {
+		name = "bearerAuth"
+		if scheme != defaultSecurityScheme {
+			name = scheme.Type + "Auth"
+		}
+	}
+	return name, scheme
+}
+
+// adds the scheme to the document and returns its name. Two authorizers that share a name but not a scheme, like two api keys read from different headers,
+// would overwrite each other : the schemes that come after the first get a number
+func (doc *openAPIDocument) addSecurityScheme(name string, scheme *SecurityScheme) string {
+	uniqueName := name
+	for i := 2; ; i++ {
+		existing, ok := doc.Components.SecuritySchemes[uniqueName]
+		if !ok {
+			doc.Components.SecuritySchemes[uniqueName] = scheme
+			return uniqueName
+		}
+		if reflect.DeepEqual(existing, scheme) {
+			return uniqueName
+		}
+		uniqueName = fmt.Sprintf("%s%d", name, i)
+	}
+}
+
+// turns a mux pattern into an OpenAPI path. Wildcards that span multiple segments ({slug...}) become plain parameters and {$} is dropped
+func toOpenAPIPath(path string) string {
+	path = strings.ReplaceAll(path, "{$}", "")
+	path = strings.ReplaceAll(path, "...}", "}")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// creates the handler that serves the OpenAPI document
+func (a *App) openAPIHandler(doc []byte) http.Handler {
+	var cors Handler
+	if a.config.CORS_Origin != "" {
+		cors = createDefaultCorsOrigin(a.config.CORS_Origin)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cors != nil {
+			cors(createCtx(w, r, a))
+		}
+		w.Header().Set("Content-Type", ApplicationJSON)
+		w.Write(doc)
+	})
+}
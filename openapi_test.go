@@ -0,0 +1,165 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+type openapi_test_query struct {
+	Team  string `paramName:"team"`
+	Limit int    `paramName:"limit" validate:"required,min=1,max=100"`
+}
+type openapi_test_input struct {
+	Name  string `json:"name" validate:"required,min=2"`
+	Email string `json:"email,omitempty" validate:"email"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING OPENAPI DOCUMENT" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		OpenAPIPath:         "/openapi.json",
+		OpenAPIInfo:         OpenAPIInfo{Title: "test api", Version: "2.0.0"},
+	})
+	auth := NewAuth(func(ctx *Ctx) (any, error) { return nil, nil })
+	auth.SecuritySchemeName = "apiKey"
+	auth.SecurityScheme = &SecurityScheme{Type: "apiKey", In: "header", Name: "X-Api-Key"}
+
+	teams := app.Router("/teams")
+	teams.Authorizer(auth)
+	NewQuery(app, func(ctx *Ctx, query openapi_test_query) (*Res[[]tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(teams, "/{team}/users")
+	NewMutation(app, func(ctx *Ctx, query any, input openapi_test_input) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Protected().Throws(http.StatusConflict, tsgen_test_user{}).Attach(teams, "/users")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, nil
+	}).Attach(app, "/teams-list")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, nil
+	}).Attach(teams, "/list")
+
+	req, err := http.NewRequest("GET", "http://localhost:8080/openapi.json", nil)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not create a new request : %s", err.Error())
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not do the request : %s", err.Error())
+	}
+	body, _ := io.ReadAll(res.Body)
+
+	var doc struct {
+		OpenAPI string      `json:"openapi"`
+		Info    OpenAPIInfo `json:"info"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name     string     `json:"name"`
+				In       string     `json:"in"`
+				Required bool       `json:"required"`
+				Schema   JSONSchema `json:"schema"`
+			} `json:"parameters"`
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema JSONSchema `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema JSONSchema `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+			Security    []map[string][]string `json:"security"`
+			OperationId string                `json:"operationId"`
+		} `json:"paths"`
+		Components struct {
+			Schemas         map[string]JSONSchema     `json:"schemas"`
+			SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf(DefaultColors.Red+"The document is not valid json : %s", body)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "test api" {
+		t.Fatalf(DefaultColors.Red+"Unexpected document header : %s", body)
+	}
+
+	query := doc.Paths["/teams/{team}/users"]["get"]
+	if len(query.Parameters) != 2 || query.Parameters[0].Name != "team" || query.Parameters[0].In != "path" ||
+		query.Parameters[1].Name != "limit" || !query.Parameters[1].Required || *query.Parameters[1].Schema.Maximum != 100 {
+		t.Fatalf(DefaultColors.Red+"Unexpected query parameters : %+v", query.Parameters)
+	}
+
+	mutation := doc.Paths["/teams/users"]["post"]
+	if mutation.RequestBody == nil || mutation.RequestBody.Content[ApplicationJSON].Schema.Ref != "#/components/schemas/openapi_test_input" {
+		t.Fatalf(DefaultColors.Red+"Unexpected request body : %s", body)
+	}
+	input := doc.Components.Schemas["openapi_test_input"]
+	if len(input.Required) != 1 || input.Required[0] != "name" || input.Properties["email"].Format != "email" || *input.Properties["name"].MinLength != 2 {
+		t.Fatalf(DefaultColors.Red+"Unexpected input schema : %+v", input)
+	}
+	if len(mutation.Security) != 1 || mutation.Security[0]["apiKey"] == nil || doc.Components.SecuritySchemes["apiKey"].Name != "X-Api-Key" {
+		t.Fatalf(DefaultColors.Red+"The protected procedure has no security requirement : %s", body)
+	}
+	conflict := mutation.Responses["409"].Content[ApplicationJSON].Schema
+	if conflict.Properties["data"] == nil || conflict.Properties["data"].Ref != "#/components/schemas/tsgen_test_user" || len(conflict.Required) != 2 {
+		t.Fatalf(DefaultColors.Red+"The declared error is not documented : %s", body)
+	}
+	if first, second := doc.Paths["/teams-list"]["get"].OperationId, doc.Paths["/teams/list"]["get"].OperationId; first == "" || first == second {
+		t.Fatalf(DefaultColors.Red+"Expected distinct operation ids, got %s and %s", first, second)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED OPENAPI DOCUMENT" + DefaultColors.Reset)
+}
+
+func TestOpenAPISecuritySchemeNames(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING OPENAPI SECURITY SCHEME NAMES" + DefaultColors.Reset)
+	app := New(&Config{DisableGenerateTS: true, DisableInfoPrinting: true})
+	newApiKeyAuth := func(header string) *Authorizer {
+		auth := NewAuth(func(ctx *Ctx) (any, error) { return nil, nil })
+		auth.SecurityScheme = &SecurityScheme{Type: "apiKey", In: "header", Name: header}
+		return auth
+	}
+	for path, header := range map[string]string{"/admins": "X-Admin-Key", "/partners": "X-Partner-Key", "/tenants": "X-Partner-Key"} {
+		router := app.Router(path)
+		router.Authorizer(newApiKeyAuth(header)).Protected()
+		NewQuery(app, func(ctx *Ctx, query any) (*Res[any], error) {
+			return nil, nil
+		}).Attach(router, "/list")
+	}
+
+	encoded, err := app.OpenAPI()
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not build the document : %s", err.Error())
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		t.Fatalf(DefaultColors.Red+"The document is not valid json : %s", encoded)
+	}
+	if len(doc.Components.SecuritySchemes) != 2 {
+		t.Fatalf(DefaultColors.Red+"Expected one scheme per header, got %+v", doc.Components.SecuritySchemes)
+	}
+	for path, header := range map[string]string{"/admins/list": "X-Admin-Key", "/partners/list": "X-Partner-Key", "/tenants/list": "X-Partner-Key"} {
+		security := doc.Paths[path]["get"].Security
+		if len(security) != 1 {
+			t.Fatalf(DefaultColors.Red+"%s has no security requirement : %s", path, encoded)
+		}
+		for name := range security[0] {
+			if doc.Components.SecuritySchemes[name].Name != header {
+				t.Fatalf(DefaultColors.Red+"%s requires %s which does not read %s : %s", path, name, header, encoded)
+			}
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED OPENAPI SECURITY SCHEME NAMES" + DefaultColors.Reset)
+}
@@ -2,15 +2,18 @@ package bluerpc
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
 )
 
 type Method string
 
 var (
-	QUERY    Method = "query"
-	MUTATION Method = "mutation"
-	STATIC   Method = "static"
+	QUERY        Method = "query"
+	MUTATION     Method = "mutation"
+	SUBSCRIPTION Method = "subscription"
+	STATIC       Method = "static"
 )
 
 type Procedure[query any, input any, output any] struct {
@@ -25,9 +28,14 @@ type Procedure[query any, input any, output any] struct {
 	acceptedContentType []string
 	queryHandler        Query[query, output]
 	mutationHandler     Mutation[query, input, output]
+	subscriptionHandler Subscription[query, output]
 
 	authorizer *Authorizer
 	protected  bool
+	errors     []procedureError
+	docs       procedureDocs
+	// the http method of a mutation set with HTTPMethod, "" for POST
+	verb string
 }
 
 type ProcedureInfo struct {
@@ -41,6 +49,21 @@ type ProcedureInfo struct {
 	handler    func(ctx *Ctx) error
 	protected  bool
 	authorizer *Authorizer
+	errors     []procedureError
+	docs       procedureDocs
+	verb       string
+}
+
+// the http method that the procedure is served on
+func (proc *ProcedureInfo) httpMethod() string {
+	switch {
+	case proc.method == MUTATION && proc.verb != "":
+		return proc.verb
+	case proc.method == MUTATION:
+		return http.MethodPost
+	default:
+		return http.MethodGet
+	}
 }
 
 // Creates a new query procedure that can be attached to groups / app root.
@@ -82,6 +105,26 @@ func NewQuery[query any, output any](app *App, queryFn Query[query, output]) *Pr
 	}
 }
 
+// Creates a new subscription procedure that can be attached to groups / app root.
+// Subscriptions are served over Server-Sent Events (text/event-stream). The handler receives an Emitter on which it can send as many outputs as it wants until it returns or the client disconnects.
+// The generic arguments specify the structure for validating query parameters (the query Params and the type of every emitted event).
+// Use any to avoid validation
+func NewSubscription[query any, output any](app *App, subscription Subscription[query, output]) *Procedure[query, any, output] {
+
+	var queryInstance query
+	checkIfQueryStruct(queryInstance)
+
+	return &Procedure[query, any, output]{
+		app:                 app,
+		validatorFn:         &app.config.ValidatorFn,
+		method:              SUBSCRIPTION,
+		subscriptionHandler: subscription,
+		acceptedContentType: []string{TextPlain, ApplicationJSON, ApplicationForm},
+		hasQuery:            !isEmptyInterface[query](),
+		hasOutput:           !isEmptyInterface[output](),
+	}
+}
+
 // Changes the validator function for this particular procedure
 func (p *Procedure[query, input, output]) Validator(fn validatorFn) *Procedure[query, input, output] {
 	p.validatorFn = &fn
@@ -120,6 +163,54 @@ func checkIfQueryStruct[query any](arg query) {
 	}
 }
 
+// Declares that the procedure can fail with an *Error of this code. data is an instance of the type of the Data of the error, nil if it has none.
+// The generated clients and the OpenAPI document type the errors of the procedure after it
+func (p *Procedure[query, input, output]) Throws(code int, data any) *Procedure[query, input, output] {
+	p.errors = append(p.errors, procedureError{code: code, dataSchema: data})
+	return p
+}
+
+// A short summary of what the procedure does. It is written in the JSDoc of the generated typescript, in the OpenAPI document and in the go client
+func (p *Procedure[query, input, output]) Summary(summary string) *Procedure[query, input, output] {
+	p.docs.summary = summary
+	return p
+}
+
+// A longer description of the procedure, written after its summary
+func (p *Procedure[query, input, output]) Describe(description string) *Procedure[query, input, output] {
+	p.docs.description = description
+	return p
+}
+
+// Groups the procedure under these tags in the OpenAPI document and in its JSDoc
+func (p *Procedure[query, input, output]) Tags(tags ...string) *Procedure[query, input, output] {
+	p.docs.tags = append(p.docs.tags, tags...)
+	return p
+}
+
+// Marks the procedure as deprecated since a version or a date (2006-01-02), "" if it does not matter.
+// The generated clients mark it with @deprecated and, when since is a date, every response it sends carries a Deprecation header so that you can see who still calls it
+func (p *Procedure[query, input, output]) Deprecated(since string) *Procedure[query, input, output] {
+	p.docs.deprecated = true
+	p.docs.deprecatedSince = since
+	return p
+}
+
+// Serves a mutation on another http method than POST : http.MethodPut, http.MethodPatch or http.MethodDelete.
+// The generated clients call it with that method, which lets caches, proxies and REST clients tell the mutation apart. Queries and subscriptions are always served on GET
+func (p *Procedure[query, input, output]) HTTPMethod(httpMethod string) *Procedure[query, input, output] {
+	if p.method != MUTATION {
+		panic(fmt.Sprintf("only mutations can change their http method, this procedure is a %s", p.method))
+	}
+	switch httpMethod = strings.ToUpper(httpMethod); httpMethod {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		p.verb = httpMethod
+	default:
+		panic(fmt.Sprintf("a mutation can not be served on %s, use POST, PUT, PATCH or DELETE", httpMethod))
+	}
+	return p
+}
+
 // Turns the procedure into a protected procedure, meaning your authorization handler will run before this runs
 func (p *Procedure[query, input, output]) Protected() *Procedure[query, input, output] {
 	p.protected = true
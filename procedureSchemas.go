@@ -0,0 +1,114 @@
+package bluerpc
+
+import (
+	"sort"
+	"strings"
+)
+
+// the dialect of every schema returned by ProcedureSchemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ProcedureSchema is the contract of a procedure as JSON Schemas (draft 2020-12). Every schema is standalone, the named types that it uses are in its own $defs
+type ProcedureSchema struct {
+	Path   string `json:"path"`
+	Method Method `json:"method"`
+
+	// the dynamic slugs of the path ({id} for example), nil if the path has none
+	Params *JSONSchema `json:"params,omitempty"`
+	// the query parameters, nil if the procedure takes none
+	Query *JSONSchema `json:"query,omitempty"`
+	// the body of a mutation, nil if the procedure takes none
+	Input *JSONSchema `json:"input,omitempty"`
+	// the response body, or the data of every event of a subscription. nil if the procedure returns nothing
+	Output *JSONSchema `json:"output,omitempty"`
+}
+
+// ProcedureSchemas returns the JSON Schemas of the query, input and output of every procedure, sorted by path.
+// They follow the json names, the required tags and the validate rules of your structs, exactly like the generated typescript and the OpenAPI document
+func (a *App) ProcedureSchemas() []ProcedureSchema {
+	var procedureSchemas []ProcedureSchema
+	a.walkProcedures(func(fullPath string, proc *ProcedureInfo) {
+		procedureSchema := ProcedureSchema{
+			Path:   fullPath,
+			Method: proc.method,
+		}
+
+		procedure := newTypeModels(a).procedure(proc, fullPath)
+		dynamicSlugNames := findDynamicSlugs(fullPath)
+		if procedure.query != nil || len(dynamicSlugNames) > 0 {
+			schemas := newJSONSchemaBuilder(a, "#/$defs/")
+			query, slugs := schemas.queryObject(procedure.query, dynamicSlugNames)
+			if len(dynamicSlugNames) > 0 {
+				params := &JSONSchema{Type: "object", Properties: slugs, Required: dynamicSlugNames}
+				procedureSchema.Params = standaloneSchema(params, schemas)
+			}
+			if procedure.query != nil {
+				procedureSchema.Query = standaloneSchema(query, schemas)
+			}
+		}
+		if procedure.input != nil {
+			schemas := newJSONSchemaBuilder(a, "#/$defs/")
+			procedureSchema.Input = standaloneSchema(schemas.schema(procedure.input), schemas)
+		}
+		schemas := newJSONSchemaBuilder(a, "#/$defs/")
+		procedureSchema.Output = standaloneSchema(schemas.schema(procedure.output), schemas)
+		procedureSchemas = append(procedureSchemas, procedureSchema)
+	})
+	sort.Slice(procedureSchemas, func(i, j int) bool {
+		return procedureSchemas[i].Path < procedureSchemas[j].Path
+	})
+	return procedureSchemas
+}
+
+// makes the schema a root schema that carries the dialect and only the definitions that it uses.
+// A root that only refers to a named type is replaced by the definition itself, which stays in $defs only if the type refers to itself
+func standaloneSchema(schema *JSONSchema, schemas *jsonSchemaBuilder) *JSONSchema {
+	root := *schema
+	if name, ok := strings.CutPrefix(schema.Ref, schemas.refPrefix); ok && schemas.defs[name] != nil {
+		root = *schemas.defs[name]
+	}
+	defs := map[string]*JSONSchema{}
+	collectDefinitions(&root, schemas, defs)
+	root.Schema = jsonSchemaDialect
+	if len(defs) > 0 {
+		root.Defs = defs
+	}
+	return &root
+}
+
+// adds every definition that the schema refers to (directly or through other definitions) to defs
+func collectDefinitions(schema *JSONSchema, schemas *jsonSchemaBuilder, defs map[string]*JSONSchema) {
+	if schema == nil {
+		return
+	}
+	if name, ok := strings.CutPrefix(schema.Ref, schemas.refPrefix); ok {
+		if _, seen := defs[name]; !seen {
+			defs[name] = schemas.defs[name]
+			collectDefinitions(schemas.defs[name], schemas, defs)
+		}
+		return
+	}
+	for _, property := range schema.Properties {
+		collectDefinitions(property, schemas, defs)
+	}
+	collectDefinitions(schema.Items, schemas, defs)
+	collectDefinitions(schema.AdditionalProperties, schemas, defs)
+}
+
+// calls fn with the full path of every procedure of the app (static routes are skipped). The procedures of a router come before its sub routers
+func (a *App) walkProcedures(fn func(fullPath string, proc *ProcedureInfo)) {
+	var walk func(router *Router, currentPath string)
+	walk = func(router *Router, currentPath string) {
+		for _, slug := range getSortedKeys(router.procedures) {
+			proc := router.procedures[slug]
+			if proc.method == STATIC {
+				continue
+			}
+			fn(currentPath+slug, proc)
+		}
+		for _, path := range getSortedKeys(router.routes) {
+			walk(router.routes[path], currentPath+path)
+		}
+	}
+	walk(a.startRoute, "")
+}
@@ -0,0 +1,84 @@
+package bluerpc
+
+import (
+	"fmt"
+	"testing"
+)
+
+type procedure_schemas_test_query struct {
+	Id     int    `paramName:"id"`
+	Fields string `paramName:"fields" validate:"required,oneof=all short"`
+}
+
+func TestProcedureSchemas(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING PROCEDURE JSON SCHEMAS" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+	})
+	orders := app.Router("/orders")
+	NewQuery(app, func(ctx *Ctx, query procedure_schemas_test_query) (*Res[tsgen_test_order], error) {
+		return nil, nil
+	}).Attach(orders, "/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input openapi_test_input) (*Res[any], error) {
+		return nil, nil
+	}).Attach(orders, "/create")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_tree], error) {
+		return nil, nil
+	}).Attach(app, "/tree")
+
+	schemas := app.ProcedureSchemas()
+	if len(schemas) != 3 {
+		t.Fatalf(DefaultColors.Red+"Expected 3 procedure schemas, got %d", len(schemas))
+	}
+
+	create := schemas[0]
+	if create.Path != "/orders/create" || create.Method != MUTATION {
+		t.Fatalf(DefaultColors.Red+"Expected the /orders/create mutation first, got %s %s", create.Method, create.Path)
+	}
+	if create.Params != nil || create.Query != nil {
+		t.Fatalf(DefaultColors.Red + "Expected /orders/create to have no params and no query")
+	}
+	if create.Input.Schema != jsonSchemaDialect || create.Input.Type != "object" {
+		t.Fatalf(DefaultColors.Red+"Expected the input to be a root object schema, got %+v", create.Input)
+	}
+	if len(create.Input.Required) != 1 || create.Input.Required[0] != "name" {
+		t.Fatalf(DefaultColors.Red+"Expected only name to be required, got %v", create.Input.Required)
+	}
+	if name := create.Input.Properties["name"]; name == nil || name.MinLength == nil || *name.MinLength != 2 {
+		t.Fatalf(DefaultColors.Red + "Expected the min validate rule on name")
+	}
+	if email := create.Input.Properties["email"]; email == nil || email.Format != "email" {
+		t.Fatalf(DefaultColors.Red + "Expected email to have the email format")
+	}
+
+	get := schemas[1]
+	if get.Path != "/orders/{id}" || get.Method != QUERY {
+		t.Fatalf(DefaultColors.Red+"Expected the /orders/{id} query second, got %s %s", get.Method, get.Path)
+	}
+	if id := get.Params.Properties["id"]; id == nil || id.Type != "integer" || len(get.Params.Required) != 1 {
+		t.Fatalf(DefaultColors.Red+"Expected a required integer id param, got %+v", get.Params)
+	}
+	if _, ok := get.Query.Properties["id"]; ok {
+		t.Fatalf(DefaultColors.Red + "Expected the id slug to be left out of the query")
+	}
+	if fields := get.Query.Properties["fields"]; fields == nil || len(fields.Enum) != 2 || len(get.Query.Required) != 1 {
+		t.Fatalf(DefaultColors.Red+"Expected a required fields enum, got %+v", get.Query)
+	}
+	if get.Output.Type != "object" || get.Output.Ref != "" {
+		t.Fatalf(DefaultColors.Red+"Expected the output to be the order object itself, got %+v", get.Output)
+	}
+	order := get.Output
+	if order == nil || order.Properties["buyer"].Ref != "#/$defs/tsgen_test_user" || len(get.Output.Defs) != 1 {
+		t.Fatalf(DefaultColors.Red+"Expected the output definitions to hold only the user, got %v", get.Output.Defs)
+	}
+	if get.Query.Defs != nil {
+		t.Fatalf(DefaultColors.Red + "Expected the query schema to carry no definitions")
+	}
+
+	tree := schemas[2]
+	if tree.Output.AdditionalProperties == nil || tree.Output.AdditionalProperties.Ref != "#/$defs/tsgen_test_tree" || tree.Output.Defs["tsgen_test_tree"] == nil {
+		t.Fatalf(DefaultColors.Red+"Expected the recursive tree to keep its own definition, got %+v", tree.Output)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED PROCEDURE JSON SCHEMAS" + DefaultColors.Reset)
+}
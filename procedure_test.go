@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
@@ -191,6 +192,37 @@ func TestDynamicQuery(t *testing.T) {
 
 }
 
+func TestDynamicQueryOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING DYNAMIC QUERY OUTPUT" + DefaultColors.Reset)
+	app := New()
+
+	type test_slug_query struct {
+		Id   int    `paramName:"id"`
+		Name string `paramName:"name"`
+	}
+	NewQuery(app, func(ctx *Ctx, query test_slug_query) (*Res[procedure_test_output], error) {
+		return nil, nil
+	}).Attach(app.Router("/{orgId}"), "/users/{id}")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[procedure_test_output], error) {
+		return nil, nil
+	}).Attach(app, "/{$}")
+
+	builder := strings.Builder{}
+	nodeToTS(&builder, nil, app.startRoute, true, "")
+	output := builder.String()
+
+	if !strings.Contains(output, "query:{ idSlug: number, name?: string, orgIdSlug: string,}") {
+		t.Fatalf(DefaultColors.Red+"The dynamic slugs are not typed arguments : %s", output)
+	}
+	if !strings.Contains(output, "rpcCall(`/${encodeURIComponent(String(query.orgIdSlug))}/users/${encodeURIComponent(String(query.idSlug))}`,'GET',{query},headers)") {
+		t.Fatalf(DefaultColors.Red+"The dynamic slugs are not interpolated in the url : %s", output)
+	}
+	if !strings.Contains(output, "rpcCall(`/`,'GET',") {
+		t.Fatalf(DefaultColors.Red+"The {$} anchor is not removed from the url : %s", output)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED DYNAMIC QUERY OUTPUT" + DefaultColors.Reset)
+}
+
 func TestMutation(t *testing.T) {
 	validate := validator.New(validator.WithRequiredStructEnabled())
 
@@ -335,3 +367,76 @@ func TestMutation(t *testing.T) {
 	fmt.Println(DefaultColors.Green + "PASSED INVALID OUTPUT" + DefaultColors.Reset)
 
 }
+
+func TestMutationHTTPMethod(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING MUTATION HTTP METHOD" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		CORS_Origin:         "http://localhost:5173",
+	})
+	NewMutation(app, func(ctx *Ctx, query any, input procedure_test_input) (*Res[procedure_test_output], error) {
+		return &Res[procedure_test_output]{Body: procedure_test_output{FieldOneOut: input.House}}, nil
+	}).HTTPMethod(http.MethodPut).Attach(app, "/houses/{id}")
+
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost:8080/houses/1", strings.NewReader(`{"House":"blue"}`))
+	req.Header.Set("Content-Type", ApplicationJSON)
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not do the request : %s", err.Error())
+	}
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != 200 || !strings.Contains(string(body), "blue") {
+		t.Fatalf(DefaultColors.Red+"Expected the PUT mutation to answer, got %d %s", res.StatusCode, string(body))
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/houses/1", strings.NewReader(`{"House":"blue"}`))
+	res, err = app.Test(req)
+	if err != nil || res.StatusCode != 405 || res.Header.Get("Allow") != http.MethodPut {
+		t.Fatalf(DefaultColors.Red+"Expected a 405 that allows PUT for a POST, got %v %+v", err, res)
+	}
+
+	req, _ = http.NewRequest(http.MethodOptions, "http://localhost:8080/houses/1", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	res, err = app.Test(req)
+	if err != nil || res.StatusCode != 204 || res.Header.Get("Access-Control-Allow-Methods") != "PUT, OPTIONS" || res.Header.Get("Access-Control-Allow-Headers") != "content-type" {
+		t.Fatalf(DefaultColors.Red+"Expected the preflight to allow PUT, got %v %+v", err, res)
+	}
+
+	dir := t.TempDir()
+	if err := app.GenerateTS(dir + "/" + typescriptFileNameForTests); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the typescript : %s", err.Error())
+	}
+	ts, _ := os.ReadFile(dir + "/" + typescriptFileNameForTests)
+	if !strings.Contains(string(ts), `type Method = "GET" | "POST" | "PUT" | "PATCH" | "DELETE"`) || !strings.Contains(string(ts), ",'PUT',parameters,headers)") {
+		t.Fatalf(DefaultColors.Red+"Expected the typescript client to call PUT : %s", string(ts))
+	}
+	goClient, err := app.GenerateGoClient("api")
+	if err != nil || !strings.Contains(string(goClient), `bluerpc.CallMutationWithMethod[`) || !strings.Contains(string(goClient), `(ctx, c.rpc, "PUT", `) {
+		t.Fatalf(DefaultColors.Red+"Expected the go client to call PUT : %v %s", err, string(goClient))
+	}
+	openAPI, err := app.OpenAPI()
+	if err != nil || !strings.Contains(string(openAPI), `"put"`) || strings.Contains(string(openAPI), `"post"`) {
+		t.Fatalf(DefaultColors.Red+"Expected the openapi operation on put : %v %s", err, string(openAPI))
+	}
+	python, _ := app.GeneratePythonClient()
+	swift, _ := app.GenerateSwiftClient()
+	kotlin, _ := app.GenerateKotlinClient("api")
+	if !strings.Contains(string(python), `self._rpc.call("PUT", `) || !strings.Contains(string(swift), `try await rpc.call("PUT", `) || !strings.Contains(string(kotlin), `rpc.call(HttpMethod.Put, `) {
+		t.Fatalf(DefaultColors.Red + "Expected the python, swift and kotlin clients to call PUT")
+	}
+	if schema := app.Schema(); schema.Procedures[0].HTTPMethod != http.MethodPut {
+		t.Fatalf(DefaultColors.Red+"Expected PUT in the schema, got %+v", schema.Procedures[0])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf(DefaultColors.Red + "Expected a query on DELETE to panic")
+		}
+		fmt.Println(DefaultColors.Green + "PASSED MUTATION HTTP METHOD" + DefaultColors.Reset)
+	}()
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, nil
+	}).HTTPMethod(http.MethodDelete)
+}
@@ -0,0 +1,423 @@
+package bluerpc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the words that python names can not take : the keywords, the builtins that the client uses and the attributes of pydantic models
+var pyReservedNames = map[string]bool{"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true, "await": true, "break": true,
+	"class": true, "continue": true, "def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true, "for": true, "from": true, "global": true,
+	"if": true, "import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true, "return": true,
+	"try": true, "while": true, "with": true, "yield": true, "self": true, "headers": true, "query": true, "input": true,
+	"copy": true, "dict": true, "json": true, "schema": true, "validate": true, "construct": true, "fields": true}
+
+// pyClientGenerator writes the typed python client of an app from the models of its types. Every struct becomes a pydantic model named after its go type
+// and the routers become classes with a method per procedure, the same tree as the go client
+type pyClientGenerator struct {
+	app    *App
+	models *typeModels
+}
+
+// GeneratePythonClient returns the source of a typed python client for every procedure of the app. It needs python 3.9+, httpx and pydantic 2.
+// The client mirrors the router tree : the query attached at /users/{id} is called with client.users.by_id(query) and returns a Response holding the output model
+func (a *App) GeneratePythonClient() ([]byte, error) {
+	// the names that the runtime of the client declares or imports
+	g := &pyClientGenerator{app: a, models: newTypeModels(a, "Rpc", "RpcError", "Response", "Int64", "T", "Annotated", "Any", "Generic", "Iterator", "Literal",
+		"Mapping", "Optional", "TypeVar", "BaseModel", "ConfigDict", "Field", "PlainSerializer", "TypeAdapter")}
+
+	routers := &strings.Builder{}
+	reserveClientRouterNames(a.startRoute, "Client", g.models.takenNames)
+	g.writeRouter(routers, a.startRoute, "Client", "")
+
+	source := &strings.Builder{}
+	source.WriteString(generatedHeader("#"))
+	source.WriteString(pyRuntime)
+	if a.config.Int64Mode.sendsString(reflect.Int64) {
+		source.WriteString("# int64 and uint64 values are sent as json strings\n" +
+			"Int64 = Annotated[int, PlainSerializer(str, return_type=str, when_used=\"json\")]\n")
+	} else {
+		source.WriteString("Int64 = int\n")
+	}
+
+	var modelNames []string
+	for _, decl := range g.models.decls {
+		if decl.goType.Kind() != reflect.Struct {
+			source.WriteString(fmt.Sprintf("\n\n%s = %s\n", decl.name, pyLiteral(decl.values)))
+			continue
+		}
+		modelNames = append(modelNames, decl.name)
+		g.writeModel(source, decl)
+	}
+	source.WriteString(routers.String())
+	if len(modelNames) > 0 {
+		source.WriteString("\n\n# the models can refer to each other (and to themselves) before they are all declared\n")
+		for _, name := range modelNames {
+			source.WriteString(name + ".model_rebuild()\n")
+		}
+	}
+	return []byte(source.String()), nil
+}
+
+func (g *pyClientGenerator) writeRouter(stringBuilder *strings.Builder, router *Router, className string, currentPath string) {
+	members := routerMembers(router)
+
+	location := currentPath
+	if location == "" {
+		location = "/"
+	}
+	stringBuilder.WriteString(fmt.Sprintf("\n\nclass %s:\n", className))
+	stringBuilder.WriteString(fmt.Sprintf("    \"\"\"%s calls the procedures under %s\"\"\"\n\n", className, location))
+	if currentPath == "" {
+		baseURL := ""
+		if g.app.config.ServerURL != "" {
+			baseURL = " = " + strconv.Quote(g.app.config.ServerURL)
+		}
+		stringBuilder.WriteString(fmt.Sprintf("    def __init__(self, base_url: str%s, http_client: Optional[httpx.Client] = None) -> None:\n", baseURL))
+		stringBuilder.WriteString("        rpc = Rpc(base_url, http_client)\n")
+	} else {
+		stringBuilder.WriteString("    def __init__(self, rpc: Rpc) -> None:\n")
+	}
+	stringBuilder.WriteString("        self._rpc = rpc\n")
+	for _, member := range members {
+		if member.router != nil {
+			stringBuilder.WriteString(fmt.Sprintf("        self.%s = %s(rpc)\n", pySnakeName(member.name), clientRouterTypeName(className, member)))
+		}
+	}
+
+	for _, member := range members {
+		if member.proc != nil {
+			g.writeProcedure(stringBuilder, pySnakeName(member.name), g.models.procedure(member.proc, currentPath+member.slug))
+		}
+	}
+	for _, member := range members {
+		if member.router != nil {
+			g.writeRouter(stringBuilder, member.router, clientRouterTypeName(className, member), currentPath+member.slug)
+		}
+	}
+}
+
+// writes the method that calls a procedure. Queries and inputs that are any are left out of the parameters,
+// and the dynamic slugs of the path that have no matching query field become string parameters
+func (g *pyClientGenerator) writeProcedure(stringBuilder *strings.Builder, methodName string, proc procedureModel) {
+	params := []string{"self"}
+	args := []string{}
+	if proc.query != nil {
+		params = append(params, "query: "+g.typeExpr(proc.query))
+		args = append(args, "query=query")
+	}
+	if proc.input != nil {
+		params = append(params, "input: "+g.typeExpr(proc.input))
+		args = append(args, "input=input")
+	}
+	var slugArgs []string
+	for _, slugName := range proc.slugs {
+		param := pySnakeName(goParamName(slugName)) + "_slug"
+		params = append(params, param+": str")
+		slugArgs = append(slugArgs, fmt.Sprintf("%q: %s", slugName, param))
+	}
+	if len(slugArgs) > 0 {
+		args = append(args, "slugs={"+strings.Join(slugArgs, ", ")+"}")
+	}
+	var errorArgs []string
+	for _, procErr := range proc.errors {
+		if procErr.data != nil {
+			errorArgs = append(errorArgs, fmt.Sprintf("%d: %s", procErr.code, g.typeExpr(procErr.data)))
+		}
+	}
+	if len(errorArgs) > 0 {
+		args = append(args, "errors={"+strings.Join(errorArgs, ", ")+"}")
+	}
+	params = append(params, "*", "headers: Optional[Mapping[str, str]] = None")
+	args = append(args, "headers=headers")
+
+	outputType := g.typeExpr(proc.output)
+	var returnType, call string
+	switch proc.method {
+	case SUBSCRIPTION:
+		returnType = fmt.Sprintf("Iterator[%s]", outputType)
+		call = fmt.Sprintf("self._rpc.subscribe(%q, %s, %s)", proc.path, outputType, strings.Join(args, ", "))
+	default:
+		returnType = fmt.Sprintf("Response[%s]", outputType)
+		call = fmt.Sprintf("self._rpc.call(%q, %q, %s, %s)", proc.httpMethod, proc.path, outputType, strings.Join(args, ", "))
+	}
+
+	stringBuilder.WriteString(fmt.Sprintf("\n    def %s(%s) -> %s:\n", methodName, strings.Join(params, ", "), returnType))
+	docLines := append([]string{fmt.Sprintf("Calls the %s at %s", proc.method, proc.path)}, goDocLines(proc.docs)...)
+	for i, line := range docLines {
+		docLines[i] = strings.TrimRight("        "+strings.NewReplacer(`\\`, `\\\\`, `"""`, `\\"\\"\\"`).Replace(line), " ")
+	}
+	stringBuilder.WriteString(`        """` + strings.TrimLeft(strings.Join(docLines, "\n"), " ") + `"""` + "\n")
+	if proc.docs.deprecated {
+		stringBuilder.WriteString(fmt.Sprintf("        warnings.warn(%q, DeprecationWarning, stacklevel=2)\n", strings.TrimSpace(proc.path+" is deprecated "+proc.docs.deprecatedMessage())))
+	}
+	stringBuilder.WriteString("        return " + call + "\n")
+}
+
+// returns the python type of a model
+func (g *pyClientGenerator) typeExpr(model *typeModel) string {
+	if model == nil {
+		return "Any"
+	}
+	switch model.kind {
+	case kindString:
+		return "str"
+	case kindBool:
+		return "bool"
+	case kindInt:
+		return "int"
+	case kindInt64:
+		return "Int64"
+	case kindFloat:
+		return "float"
+	case kindTime:
+		return "datetime"
+	case kindOptional:
+		return "Optional[" + g.typeExpr(model.elem) + "]"
+	case kindList:
+		return "list[" + g.typeExpr(model.elem) + "]"
+	case kindMap:
+		return "dict[str, " + g.typeExpr(model.elem) + "]"
+	case kindObject, kindEnum:
+		return model.decl.name
+	default:
+		return "Any"
+	}
+}
+
+// writes the pydantic model of a struct
+func (g *pyClientGenerator) writeModel(source *strings.Builder, decl *typeDecl) {
+	source.WriteString(fmt.Sprintf("\n\nclass %s(BaseModel):\n", decl.name))
+	source.WriteString("    model_config = ConfigDict(populate_by_name=True)\n")
+	if len(decl.fields) > 0 {
+		source.WriteString("\n")
+	}
+	taken := map[string]bool{}
+	for _, field := range decl.fields {
+		fieldName := pySnakeName(field.name)
+		for i := 2; taken[fieldName]; i++ {
+			fieldName = pySnakeName(field.name) + "_" + strconv.Itoa(i)
+		}
+		taken[fieldName] = true
+
+		fieldType := g.typeExpr(field.model)
+		options := []string{"alias=" + strconv.Quote(field.name)}
+		if field.doc != "" {
+			options = append(options, "description="+strconv.Quote(field.doc))
+		}
+		if !field.required {
+			if field.model.kind != kindOptional {
+				fieldType = "Optional[" + fieldType + "]"
+			}
+			options = append([]string{"default=None"}, options...)
+		}
+		source.WriteString(fmt.Sprintf("    %s: %s = Field(%s)\n", fieldName, fieldType, strings.Join(options, ", ")))
+	}
+}
+
+// returns the Literal of the values of an enum
+func pyLiteral(values []any) string {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.String:
+			literals[i] = strconv.Quote(v.String())
+		case reflect.Bool:
+			literals[i] = "False"
+			if v.Bool() {
+				literals[i] = "True"
+			}
+		default:
+			literals[i] = fmt.Sprint(value)
+		}
+	}
+	return "Literal[" + strings.Join(literals, ", ") + "]"
+}
+
+// turns a go or json name into a snake case python name : ById becomes by_id and fieldOneOut becomes field_one_out
+func pySnakeName(name string) string {
+	var builder strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			// a new word starts on an upper case letter that follows a lower case one or that starts a word after an acronym (HTTPServer)
+			if i > 0 && builder.Len() > 0 && !strings.HasSuffix(builder.String(), "_") &&
+				(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(r)
+		default:
+			if builder.Len() > 0 && !strings.HasSuffix(builder.String(), "_") {
+				builder.WriteByte('_')
+			}
+		}
+	}
+	snake := strings.Trim(builder.String(), "_")
+	if snake == "" || (snake[0] >= '0' && snake[0] <= '9') {
+		snake = "f_" + snake
+	}
+	if pyReservedNames[snake] || strings.HasPrefix(snake, "model_") {
+		snake += "_"
+	}
+	return snake
+}
+
+// the part of the python client that does not depend on the procedures : the responses, the errors and the calls
+const pyRuntime = `from __future__ import annotations
+
+import json
+import warnings
+from dataclasses import dataclass
+from datetime import datetime
+from typing import Annotated, Any, Generic, Iterator, Literal, Mapping, Optional, TypeVar
+from urllib.parse import quote
+
+import httpx
+from pydantic import BaseModel, ConfigDict, Field, PlainSerializer, TypeAdapter
+
+T = TypeVar("T")
+
+
+@dataclass
+class Response(Generic[T]):
+    body: T
+    status: int
+    headers: httpx.Headers
+
+
+class RpcError(Exception):
+    """An error response of the server. data holds the data of the error, parsed into its model when the procedure declared it"""
+
+    def __init__(self, status: int, message: str, data: Any = None) -> None:
+        super().__init__(message)
+        self.status = status
+        self.message = message
+        self.data = data
+
+
+def _rpc_error(status: int, text: str, errors: Optional[Mapping[int, Any]]) -> RpcError:
+    message, data = text.strip(), None
+    try:
+        body = json.loads(text)
+    except ValueError:
+        body = None
+    if isinstance(body, dict):
+        message = body.get("message") or message
+        data = body.get("data")
+    if data is not None and errors and status in errors:
+        data = TypeAdapter(errors[status]).validate_python(data)
+    return RpcError(status, message or httpx.codes.get_reason_phrase(status), data)
+
+
+def _parse(output: Any, text: str) -> Any:
+    if text == "":
+        return None
+    try:
+        body = json.loads(text)
+    except ValueError:
+        # bodies that are not json are plain text
+        body = text
+    return TypeAdapter(output).validate_python(body)
+
+
+def _encode_query_value(value: Any) -> str:
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    if isinstance(value, list):
+        return ",".join(_encode_query_value(item) for item in value)
+    return str(value)
+
+
+class Rpc:
+    """Sends the calls of the client. Give it your own httpx.Client to set the headers, the timeout or the authentication of every call"""
+
+    def __init__(self, base_url: str, http_client: Optional[httpx.Client] = None) -> None:
+        self.base_url = base_url.rstrip("/")
+        self.http_client = http_client or httpx.Client()
+
+    def _address(self, path: str, query: Optional[BaseModel], slugs: Optional[Mapping[str, str]]) -> tuple[str, dict[str, str]]:
+        # the fields of the query fill the dynamic slugs that share their name, every other field becomes a query parameter
+        values = dict(slugs or {})
+        params: dict[str, str] = {}
+        slug_names = [segment[1:-1].removesuffix("...") for segment in path.split("/") if segment.startswith("{") and segment.endswith("}")]
+        if query is not None:
+            for key, value in query.model_dump(by_alias=True, mode="json").items():
+                if value is None:
+                    continue
+                if key in slug_names:
+                    values[key] = _encode_query_value(value)
+                else:
+                    params[key] = _encode_query_value(value)
+        segments = []
+        for segment in path.split("/"):
+            if segment.startswith("{") and segment.endswith("}"):
+                name = segment[1:-1]
+                if name == "$":
+                    segment = ""
+                elif name.removesuffix("...") not in values:
+                    raise ValueError(f"no value was given for the {name.removesuffix('...')} slug of {path}")
+                elif name.endswith("..."):
+                    # wildcards that span multiple segments keep their slashes
+                    segment = "/".join(quote(part, safe="") for part in values[name.removesuffix("...")].split("/"))
+                else:
+                    segment = quote(values[name], safe="")
+            segments.append(segment)
+        return "/".join(segments), params
+
+    def call(
+        self,
+        method: str,
+        path: str,
+        output: Any,
+        query: Optional[BaseModel] = None,
+        input: Any = None,
+        slugs: Optional[Mapping[str, str]] = None,
+        errors: Optional[Mapping[int, Any]] = None,
+        headers: Optional[Mapping[str, str]] = None,
+    ) -> Response[Any]:
+        url, params = self._address(path, query, slugs)
+        request_headers = dict(headers or {})
+        content = None
+        if input is not None:
+            content = TypeAdapter(type(input)).dump_json(input, by_alias=True)
+            request_headers["Content-Type"] = "application/json"
+        response = self.http_client.request(method, self.base_url + url, params=params, content=content, headers=request_headers)
+        if response.status_code >= 400:
+            raise _rpc_error(response.status_code, response.text, errors)
+        return Response(body=_parse(output, response.text), status=response.status_code, headers=response.headers)
+
+    def subscribe(
+        self,
+        path: str,
+        output: Any,
+        query: Optional[BaseModel] = None,
+        slugs: Optional[Mapping[str, str]] = None,
+        headers: Optional[Mapping[str, str]] = None,
+    ) -> Iterator[Any]:
+        url, params = self._address(path, query, slugs)
+        request_headers = {**(headers or {}), "Accept": "text/event-stream"}
+        with self.http_client.stream("GET", self.base_url + url, params=params, headers=request_headers) as response:
+            if response.status_code >= 400:
+                response.read()
+                raise _rpc_error(response.status_code, response.text, None)
+            name, data = "message", []
+            for line in response.iter_lines():
+                if line.startswith("event:"):
+                    name = line[len("event:"):].strip()
+                elif line.startswith("data:"):
+                    data.append(line[len("data:"):].removeprefix(" "))
+                elif line == "" and data:
+                    if name == "error":
+                        raise _rpc_error(500, "\n".join(data), None)
+                    yield _parse(output, "\n".join(data))
+                    name, data = "message", []
+
+
+`
@@ -2,78 +2,511 @@ package bluerpc
 
 import (
 	"fmt"
-	"os"
 	"strings"
 )
 
 func generateTs(app *App) error {
-	builder := strings.Builder{}
-	addRpcFunc(&builder, app)
+	return app.GenerateTS(app.config.OutputPath)
+}
 
-	builder.WriteString("export const rpcAPI =")
-	nodeToTS(&builder, app.startRoute, true, "")
-	builder.WriteString("as const;")
+// returns the source of the typescript client
+func generateTsSource(app *App) string {
+	// the api object is generated first so that every type it uses is known by the time the declarations are written
+	types := newTSTypeRegistry(app)
+	api := strings.Builder{}
+	nodeToTS(&api, types, app.startRoute, true, "")
 
-	file, err := os.Create(app.config.OutputPath)
-	if err != nil {
-		return err
+	builder := strings.Builder{}
+	builder.WriteString(generatedHeader("//"))
+	if app.config.GenerateZod {
+		builder.WriteString("import { z } from \"zod\";\n")
 	}
-	defer file.Close()
+	writeTsRuntime(&builder, app)
+	writeTsTypes(&builder, types)
+	writeTsAPIFactory(&builder, "createRpcAPI", api.String())
+	writeRpcAPI(&builder, app)
+	return builder.String()
+}
 
-	_, err = file.WriteString(builder.String())
-	return err
+// writes the functions that every call goes through : fetch, the websocket link and the batch link
+func writeTsRuntime(builder *strings.Builder, app *App) {
+	addRpcFunc(builder, app)
+	if app.config.WebSocketPath != "" {
+		addWebSocketLink(builder, app)
+	}
+	if app.config.BatchPath != "" {
+		addBatchLink(builder, app)
+	}
+}
+
+// writes the declarations of the named types, their bigint specs and their zod schemas
+func writeTsTypes(builder *strings.Builder, types *tsTypeRegistry) {
+	types.writeDeclarations(builder)
+	types.writeBigIntSpecs(builder)
+	types.writeZodSchemas(builder)
+}
+
+// writes a function that returns the api object bound to a client.
+// Every client shares the same procedures, the calls of the procedures are bound to the options of the client they belong to
+func writeTsAPIFactory(builder *strings.Builder, name string, api string) {
+	builder.WriteString("function " + name + "(client: RpcClientOptions) {\n" +
+		"  function rpcCall<T>(apiRoute: string, method: Method, params?: { query?: any; input?: any }, headers?: HeadersInit): Promise<RpcResponse<T>> {\n" +
+		"    return clientCall<T>(client, apiRoute, method, params, headers);\n" +
+		"  }\n" +
+		"  function rpcSubscribe<T>(apiRoute: string, params?: { query?: any }, headers?: HeadersInit): RpcSubscription<T> {\n" +
+		"    return clientSubscribe<T>(client, apiRoute, params, headers);\n" +
+		"  }\n" +
+		"  return ")
+	builder.WriteString(formatTS(api, "  "))
+	builder.WriteString(" as const;\n}\n")
+}
 
+// writes rpcAPI, createClient and rpcQuery on top of createRpcAPI
+func writeRpcAPI(builder *strings.Builder, app *App) {
+	if app.config.WebSocketPath == "" {
+		builder.WriteString("export const rpcAPI = createRpcAPI({});\n" +
+			"export type RpcAPI = typeof rpcAPI;\n" +
+			"// creates a client with its own base url, fetch, headers, timeout, signal and interceptors. It has the same procedures as rpcAPI\n" +
+			"export function createClient(options: RpcClientOptions = {}): RpcAPI {\n" +
+			"  return createRpcAPI(options);\n" +
+			"}\n")
+		writeTanStackQuery(builder, app)
+		return
+	}
+	// the clients are registered so that connectWebSocket can find their options
+	builder.WriteString("export const rpcAPI = createRpcAPI(defaultClient);\n" +
+		"clientOptions.set(rpcAPI, defaultClient);\n" +
+		"export type RpcAPI = typeof rpcAPI;\n" +
+		"// creates a client with its own base url, fetch, headers, timeout, signal and interceptors. It has the same procedures as rpcAPI\n" +
+		"export function createClient(options: RpcClientOptions = {}): RpcAPI {\n" +
+		"  const api = createRpcAPI(options);\n" +
+		"  clientOptions.set(api, options);\n" +
+		"  return api;\n" +
+		"}\n")
+	writeTanStackQuery(builder, app)
 }
 
 func addRpcFunc(builder *strings.Builder, app *App) {
 
 	var host string
 	if app.config.ServerURL != "" {
-		host = fmt.Sprintf(`const host = "%s%s";`, app.config.ServerURL, app.port)
+		host = fmt.Sprintf(`const host = "%s";`, app.config.ServerURL)
 	} else {
 		host = `const host = "";`
 	}
 
+	// when websockets are enabled every call is routed through the websocket link once it is connected
+	// and when batching is enabled every call made in the same tick is sent in a single batch request
+	var webSocketCall, webSocketSubscribe, batchCall string
+	if app.config.WebSocketPath != "" {
+		webSocketCall = "  const webSocketLink = webSocketLinks.get(client);\n" +
+			"  if (webSocketLink) {\n" +
+			"    return webSocketLink.call<T>(method, buildPath(apiRoute, params?.query), params?.input, await requestHeaders(client, headers));\n" +
+			"  }\n"
+		webSocketSubscribe = "  const webSocketLink = webSocketLinks.get(client);\n" +
+			"  if (webSocketLink) {\n" +
+			"    return webSocketLink.subscribe<T>(buildPath(apiRoute, params?.query), requestHeaders(client, headers));\n" +
+			"  }\n"
+	}
+	if app.config.BatchPath != "" {
+		batchCall = "  if (batchingEnabled) {\n" +
+			"    return batchCall<T>(client, method, buildPath(apiRoute, params?.query), params?.input, headers, () => rpcFetch<T>(client, apiRoute, method, params, headers));\n" +
+			"  }\n"
+	}
+
 	text := "/* eslint-disable @typescript-eslint/no-explicit-any */\n" +
-		"type Method = \"GET\" | \"POST\"\n" +
-		"async function rpcCall<T>(\n" +
+		"type Method = \"GET\" | \"POST\" | \"PUT\" | \"PATCH\" | \"DELETE\"\n" +
+		"// the frame of a call sent through the websocket or in a batch, the method is only needed by the mutations that are not served on POST\n" +
+		"function callFrame(method: Method, path: string, input: any, headers?: HeadersInit) {\n" +
+		"  return { type: method === 'GET' ? 'query' : 'mutation', method: method === 'GET' || method === 'POST' ? undefined : method, path, input, headers: headersToRecord(headers) };\n" +
+		"}\n" +
+		host + "\n" +
+		"function buildPath(apiRoute: string, query?: any): string {\n" +
+		"  let path = apiRoute;\n" +
+		"  if (query && Object.keys(query).length !== 0) {\n" +
+		"    path += `?${Object.keys(query)\n" +
+		"      .filter(key => !key.includes('Slug') && query[key])\n" +
+		"      .map(key => `${encodeURIComponent(key)}=${encodeURIComponent(query[key])}`)\n" +
+		"      .join('&')}`\n" +
+		"  }\n" +
+		"  return path;\n" +
+		"}\n" +
+		"function headersToRecord(headers?: HeadersInit): Record<string, string> | undefined {\n" +
+		"  if (!headers) return undefined;\n" +
+		"  const record: Record<string, string> = {};\n" +
+		"  new Headers(headers).forEach((value, key) => (record[key] = value));\n" +
+		"  return record;\n" +
+		"}\n" +
+		"// bigints are sent as strings, which is how the server reads int64 values when they are not sent as numbers\n" +
+		"function stringifyJSON(value: any): string {\n" +
+		"  return JSON.stringify(value, (_, v) => (typeof v === 'bigint' ? v.toString() : v));\n" +
+		"}\n" +
+		"// the error of a response. Procedures type the errors they declared with Throws, every other error is an RpcUnknownError\n" +
+		"export type RpcUnknownError = { status: number; message: string; data?: undefined }\n" +
+		"export type RpcResponse<T, E = RpcUnknownError> =\n" +
+		"  | { ok: true; body: T; status: number; headers: Headers }\n" +
+		"  | { ok: false; error: E; status: number; headers: Headers }\n" +
+		"// thrown by rpcBody and by the subscriptions that failed\n" +
+		"export class RpcError<E extends { status: number; message: string } = RpcUnknownError> extends Error {\n" +
+		"  readonly error: E;\n" +
+		"  readonly status: number;\n" +
+		"  readonly headers: Headers;\n" +
+		"  constructor(error: E, headers: Headers = new Headers()) {\n" +
+		"    super(error.message);\n" +
+		"    this.name = 'RpcError';\n" +
+		"    this.error = error;\n" +
+		"    this.status = error.status;\n" +
+		"    this.headers = headers;\n" +
+		"  }\n" +
+		"}\n" +
+		"// returns the body of a successful response and throws an RpcError holding its error otherwise\n" +
+		"export function rpcBody<T, E extends { status: number; message: string }>(res: RpcResponse<T, E>): T {\n" +
+		"  if (!res.ok) throw new RpcError(res.error, res.headers);\n" +
+		"  return res.body;\n" +
+		"}\n" +
+		"// the server sends errors as { message, data } with a status of 400 or more\n" +
+		"function rpcResponse<T>(status: number, headers: Headers, body: any): RpcResponse<T> {\n" +
+		"  if (status < 400) return { ok: true, body: body as T, status, headers };\n" +
+		"  const message = typeof body === 'string' && body !== '' ? body : `request failed with status ${status}`;\n" +
+		"  const error = typeof body === 'object' && body !== null && !(body instanceof Blob) ? body : { message };\n" +
+		"  return { ok: false, error: { ...error, status }, status, headers };\n" +
+		"}\n" +
+		"export type RpcRequest = { url: string; init: RequestInit }\n" +
+		"// the options of a client (see createClient). rpcAPI is a client without any option\n" +
+		"export type RpcClientOptions = {\n" +
+		"  // the address of the server. Defaults to the ServerURL of the app\n" +
+		"  baseUrl?: string;\n" +
+		"  // the fetch implementation, for example the fetch of your SSR framework or a mock in your tests\n" +
+		"  fetch?: (input: string, init?: RequestInit) => Promise<Response>;\n" +
+		"  // headers sent with every call. The headers given to a single call override them\n" +
+		"  headers?: HeadersInit | (() => HeadersInit | Promise<HeadersInit>);\n" +
+		"  credentials?: RequestCredentials;\n" +
+		"  // aborts the calls that take longer than this many milliseconds. Subscriptions are not affected\n" +
+		"  timeout?: number;\n" +
+		"  // aborts every call and closes every subscription of the client\n" +
+		"  signal?: AbortSignal;\n" +
+		"  // called before every request is sent. Return a request in order to replace it\n" +
+		"  onRequest?: (request: RpcRequest) => RpcRequest | void | Promise<RpcRequest | void>;\n" +
+		"  // called with every response before it is read. Return a response in order to replace it\n" +
+		"  onResponse?: (response: Response, request: RpcRequest) => Response | void | Promise<Response | void>;\n" +
+		"}\n" +
+		"async function requestHeaders(client: RpcClientOptions, headers?: HeadersInit): Promise<Headers> {\n" +
+		"  const merged = new Headers(typeof client.headers === 'function' ? await client.headers() : client.headers);\n" +
+		"  new Headers(headers).forEach((value, key) => merged.set(key, value));\n" +
+		"  return merged;\n" +
+		"}\n" +
+		"// combines the signal of the client with a timeout. done must be called once the request is over\n" +
+		"function requestSignal(client: RpcClientOptions, timeout?: number): { signal?: AbortSignal; done: () => void } {\n" +
+		"  if (!timeout) return { signal: client.signal, done: () => {} };\n" +
+		"  const controller = new AbortController();\n" +
+		"  const abort = () => controller.abort(client.signal?.reason);\n" +
+		"  if (client.signal?.aborted) abort();\n" +
+		"  client.signal?.addEventListener('abort', abort);\n" +
+		"  const timer = setTimeout(() => controller.abort(new Error(`the request timed out after ${timeout}ms`)), timeout);\n" +
+		"  return {\n" +
+		"    signal: controller.signal,\n" +
+		"    done: () => {\n" +
+		"      clearTimeout(timer);\n" +
+		"      client.signal?.removeEventListener('abort', abort);\n" +
+		"    },\n" +
+		"  };\n" +
+		"}\n" +
+		"async function rpcSend(client: RpcClientOptions, request: RpcRequest): Promise<Response> {\n" +
+		"  request = (await client.onRequest?.(request)) || request;\n" +
+		"  const fetchFn = client.fetch ?? fetch;\n" +
+		"  const res = await fetchFn(request.url, request.init);\n" +
+		"  return (await client.onResponse?.(res, request)) || res;\n" +
+		"}\n" +
+		"async function clientCall<T>(\n" +
+		"  client: RpcClientOptions,\n" +
+		"  apiRoute: string,\n" +
+		"  method: Method,\n" +
+		"  params?: { query?: any; input?: any },\n" +
+		"  headers?: HeadersInit\n" +
+		"): Promise<RpcResponse<T>> {\n" +
+		webSocketCall +
+		batchCall +
+		"  return rpcFetch<T>(client, apiRoute, method, params, headers);\n" +
+		"}\n" +
+		"async function rpcFetch<T>(\n" +
+		"  client: RpcClientOptions,\n" +
 		"  apiRoute: string,\n" +
 		"  method: Method,\n" +
 		"  params?: { query?: any; input?: any },\n" +
 		"  headers?: HeadersInit\n" +
-		"): Promise<{ body: T; status: number; headers: Headers }> {\n" +
-		"  const requestOptions: RequestInit = {\n" +
-		"    method: method,\n" +
-		"    headers: headers,\n" +
+		"): Promise<RpcResponse<T>> {\n" +
+		"  const { signal, done } = requestSignal(client, client.timeout);\n" +
+		"  try {\n" +
+		"    const requestOptions: RequestInit = {\n" +
+		"      method: method,\n" +
+		"      headers: await requestHeaders(client, headers),\n" +
+		"      credentials: client.credentials,\n" +
+		"      signal: signal,\n" +
+		"    };\n" +
+		"    if (params?.input) {\n" +
+		"      requestOptions.body = stringifyJSON(params.input);\n" +
+		"    }\n" +
+		"    const url = (client.baseUrl ?? host) + buildPath(apiRoute, params?.query)\n" +
+		"    const res = await rpcSend(client, { url, init: requestOptions });\n" +
+		"    const contentType = res.headers.get('content-type');\n" +
+		"    let body: any;\n" +
+		"    if (contentType?.includes('application/json')) {\n" +
+		"      body = await res.json();\n" +
+		"    } else if (contentType?.includes('text')) {\n" +
+		"      body = await res.text();\n" +
+		"    } else {\n" +
+		"      body = await res.blob(); // or arrayBuffer, depending on the expected response\n" +
+		"    }\n" +
+		"    return rpcResponse<T>(res.status, res.headers, body);\n" +
+		"  } finally {\n" +
+		"    done();\n" +
+		"  }\n" +
+		"}\n" +
+		"export type RpcSubscription<T> = AsyncIterable<T> & { unsubscribe: () => void }\n" +
+		"function clientSubscribe<T>(\n" +
+		"  client: RpcClientOptions,\n" +
+		"  apiRoute: string,\n" +
+		"  params?: { query?: any },\n" +
+		"  headers?: HeadersInit\n" +
+		"): RpcSubscription<T> {\n" +
+		webSocketSubscribe +
+		"  const controller = new AbortController();\n" +
+		"  const abort = () => controller.abort();\n" +
+		"  const url = (client.baseUrl ?? host) + buildPath(apiRoute, params?.query)\n" +
+		"  async function* iterate(): AsyncGenerator<T> {\n" +
+		"    if (client.signal?.aborted) return;\n" +
+		"    client.signal?.addEventListener('abort', abort);\n" +
+		"    try {\n" +
+		"      const init: RequestInit = { method: 'GET', headers: await requestHeaders(client, headers), credentials: client.credentials, signal: controller.signal };\n" +
+		"      const res = await rpcSend(client, { url, init });\n" +
+		"      if (!res.ok || !res.body) {\n" +
+		"        const body = await res.json().catch(() => undefined);\n" +
+		"        throw new RpcError({ status: res.status, message: body?.message ?? `subscription to ${apiRoute} failed with status ${res.status}`, data: body?.data }, res.headers);\n" +
+		"      }\n" +
+		"      const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();\n" +
+		"      let buffer = '';\n" +
+		"      try {\n" +
+		"        while (true) {\n" +
+		"          const { value, done } = await reader.read();\n" +
+		"          if (done) return;\n" +
+		"          buffer += value;\n" +
+		"          let boundary = buffer.indexOf('\\n\\n');\n" +
+		"          while (boundary !== -1) {\n" +
+		"            const chunk = buffer.slice(0, boundary);\n" +
+		"            buffer = buffer.slice(boundary + 2);\n" +
+		"            boundary = buffer.indexOf('\\n\\n');\n" +
+		"            let event = 'message';\n" +
+		"            const data: string[] = [];\n" +
+		"            for (const line of chunk.split('\\n')) {\n" +
+		"              if (line.startsWith('event:')) event = line.slice(6).trim();\n" +
+		"              else if (line.startsWith('data:')) data.push(line.slice(5).trimStart());\n" +
+		"            }\n" +
+		"            if (data.length === 0) continue;\n" +
+		"            const parsed = JSON.parse(data.join('\\n'));\n" +
+		"            if (event === 'error') throw new RpcError({ ...parsed, status: 500 }, res.headers);\n" +
+		"            yield parsed as T;\n" +
+		"          }\n" +
+		"        }\n" +
+		"      } finally {\n" +
+		"        reader.releaseLock();\n" +
+		"      }\n" +
+		"    } catch (err) {\n" +
+		"      if (controller.signal.aborted) return;\n" +
+		"      throw err;\n" +
+		"    } finally {\n" +
+		"      client.signal?.removeEventListener('abort', abort);\n" +
+		"    }\n" +
+		"  }\n" +
+		"  return {\n" +
+		"    [Symbol.asyncIterator]: iterate,\n" +
+		"    unsubscribe: () => controller.abort(),\n" +
 		"  };\n" +
-		"  if (params?.input) {\n" +
-		"    requestOptions.body = JSON.stringify(params.input);\n" +
+		"}\n"
+	builder.WriteString(text)
+}
+
+// adds the websocket link. Calling connectWebSocket() makes every procedure of rpcAPI go through a single websocket connection until disconnectWebSocket() is called.
+// Every client has its own link, connectWebSocket(url, client) connects a client made with createClient
+func addWebSocketLink(builder *strings.Builder, app *App) {
+
+	builder.WriteString(fmt.Sprintf("const webSocketPath = \"%s\";\n", app.config.WebSocketPath))
+
+	text := "type SocketFrame = { id: string; type: 'result' | 'data' | 'error' | 'complete'; status?: number; body?: any }\n" +
+		"export class WebSocketLink {\n" +
+		"  private socket: WebSocket;\n" +
+		"  private ready: Promise<void>;\n" +
+		"  private nextId = 0;\n" +
+		"  private handlers = new Map<string, (frame: SocketFrame) => void>();\n" +
+		"  constructor(url: string) {\n" +
+		"    this.socket = new WebSocket(url);\n" +
+		"    this.ready = new Promise((resolve, reject) => {\n" +
+		"      this.socket.addEventListener('open', () => resolve());\n" +
+		"      this.socket.addEventListener('error', () => reject(new Error(`could not connect to ${url}`)));\n" +
+		"    });\n" +
+		"    this.socket.addEventListener('message', (event) => {\n" +
+		"      const frame = JSON.parse(event.data) as SocketFrame;\n" +
+		"      this.handlers.get(frame.id)?.(frame);\n" +
+		"    });\n" +
+		"    this.socket.addEventListener('close', () => {\n" +
+		"      this.handlers.forEach((handler, id) => handler({ id, type: 'error', status: 499, body: { message: 'websocket closed' } }));\n" +
+		"      this.handlers.clear();\n" +
+		"    });\n" +
 		"  }\n" +
-		host + "\n" +
-		"  let path = apiRoute;\n" +
-		"  if (params?.query && Object.keys(params.query).length !== 0) {\n" +
-		"    path += `?${Object.keys(params.query)\n" +
-		"      .filter(key => !key.includes('Slug') && params.query[key])\n" +
-		"      .map(key => `${encodeURIComponent(key)}=${encodeURIComponent(params.query[key])}`)\n" +
-		"      .join('&')}`\n" +
+		"  private async send(frame: object, handler: (frame: SocketFrame) => void): Promise<string> {\n" +
+		"    await this.ready;\n" +
+		"    const id = String(this.nextId++);\n" +
+		"    this.handlers.set(id, handler);\n" +
+		"    this.socket.send(stringifyJSON({ ...frame, id }));\n" +
+		"    return id;\n" +
 		"  }\n" +
-		"  const url = host + path\n" +
-		"  const res = await fetch(url, requestOptions);\n" +
-		"  const contentType = res.headers.get('content-type');\n" +
-		"  let body: any;\n" +
-		"  if (contentType?.includes('application/json')) {\n" +
-		"    body = await res.json();\n" +
-		"  } else if (contentType?.includes('text')) {\n" +
-		"    body = await res.text();\n" +
-		"  } else {\n" +
-		"    body = await res.blob(); // or arrayBuffer, depending on the expected response\n" +
+		"  call<T>(method: Method, path: string, input?: any, headers?: HeadersInit): Promise<RpcResponse<T>> {\n" +
+		"    return new Promise((resolve, reject) => {\n" +
+		"      this.send(callFrame(method, path, input, headers), (frame) => {\n" +
+		"        this.handlers.delete(frame.id);\n" +
+		"        resolve(rpcResponse<T>(frame.status ?? 200, new Headers(), frame.body));\n" +
+		"      }).catch(reject);\n" +
+		"    });\n" +
+		"  }\n" +
+		"  subscribe<T>(path: string, headers?: HeadersInit | Promise<HeadersInit>): RpcSubscription<T> {\n" +
+		"    const queue: T[] = [];\n" +
+		"    let wake: (() => void) | undefined;\n" +
+		"    let finished = false;\n" +
+		"    let failure: Error | undefined;\n" +
+		"    const started = Promise.resolve(headers).then((resolved) => this.send({ type: 'subscription', path, headers: headersToRecord(resolved) }, (frame) => {\n" +
+		"      if (frame.type === 'data') {\n" +
+		"        queue.push(frame.body as T);\n" +
+		"      } else {\n" +
+		"        if (frame.type === 'error') failure = new RpcError({ status: frame.status ?? 500, message: frame.body?.message ?? `subscription to ${path} failed`, data: frame.body?.data });\n" +
+		"        finished = true;\n" +
+		"        this.handlers.delete(frame.id);\n" +
+		"      }\n" +
+		"      wake?.();\n" +
+		"    }));\n" +
+		"    return {\n" +
+		"      async *[Symbol.asyncIterator]() {\n" +
+		"        await started;\n" +
+		"        while (true) {\n" +
+		"          if (queue.length !== 0) {\n" +
+		"            yield queue.shift() as T;\n" +
+		"            continue;\n" +
+		"          }\n" +
+		"          if (failure) throw failure;\n" +
+		"          if (finished) return;\n" +
+		"          await new Promise<void>((resolve) => (wake = resolve));\n" +
+		"          wake = undefined;\n" +
+		"        }\n" +
+		"      },\n" +
+		"      unsubscribe: () => {\n" +
+		"        finished = true;\n" +
+		"        wake?.();\n" +
+		"        started.then((id) => {\n" +
+		"          this.handlers.delete(id);\n" +
+		"          this.socket.send(JSON.stringify({ id, type: 'stop' }));\n" +
+		"        });\n" +
+		"      },\n" +
+		"    };\n" +
+		"  }\n" +
+		"  close() {\n" +
+		"    this.socket.close();\n" +
+		"  }\n" +
+		"}\n" +
+		"// the options of rpcAPI\n" +
+		"const defaultClient: RpcClientOptions = {};\n" +
+		"// the options of every client, by client\n" +
+		"const clientOptions = new WeakMap<object, RpcClientOptions>();\n" +
+		"// every client has its own connection, with its own url and headers\n" +
+		"const webSocketLinks = new WeakMap<RpcClientOptions, WebSocketLink>();\n" +
+		"function optionsOf(client?: object): RpcClientOptions {\n" +
+		"  const options = client ? clientOptions.get(client) : defaultClient;\n" +
+		"  if (!options) throw new Error('the client was not made with createClient');\n" +
+		"  return options;\n" +
+		"}\n" +
+		"// routes every call of the client (rpcAPI by default) through a single websocket connection. The url defaults to the websocket path on the base url of the client\n" +
+		"export function connectWebSocket(url?: string, client?: object): WebSocketLink {\n" +
+		"  const options = optionsOf(client);\n" +
+		"  if (!url) {\n" +
+		"    const base = options.baseUrl ?? (host || `${location.protocol}//${location.host}`);\n" +
+		"    url = base.replace(/^http/, 'ws') + webSocketPath;\n" +
+		"  }\n" +
+		"  webSocketLinks.get(options)?.close();\n" +
+		"  const link = new WebSocketLink(url);\n" +
+		"  webSocketLinks.set(options, link);\n" +
+		"  return link;\n" +
+		"}\n" +
+		"export function disconnectWebSocket(client?: object) {\n" +
+		"  const options = optionsOf(client);\n" +
+		"  webSocketLinks.get(options)?.close();\n" +
+		"  webSocketLinks.delete(options);\n" +
+		"}\n"
+	builder.WriteString(text)
+}
+
+// adds the batching link. Every call made in the same tick is queued and sent to the batch endpoint in a single request.
+// Batching can be turned off at runtime with setBatching(false)
+func addBatchLink(builder *strings.Builder, app *App) {
+
+	builder.WriteString(fmt.Sprintf("const batchPath = \"%s\";\n", app.config.BatchPath))
+	builder.WriteString(fmt.Sprintf("const maxBatchSize = %d;\n", app.config.MaxBatchSize))
+
+	text := "type BatchEntry = { call: object; single: () => Promise<RpcResponse<any>>; resolve: (res: RpcResponse<any>) => void; reject: (err: unknown) => void }\n" +
+		"type BatchResult = { status: number; headers?: Record<string, string>; body?: any }\n" +
+		"let batchingEnabled = true;\n" +
+		"// every client batches its own calls, with its own options\n" +
+		"const batchQueues = new WeakMap<RpcClientOptions, BatchEntry[]>();\n" +
+		"export function setBatching(enabled: boolean) {\n" +
+		"  batchingEnabled = enabled;\n" +
+		"}\n" +
+		"function batchCall<T>(client: RpcClientOptions, method: Method, path: string, input: any, headers: HeadersInit | undefined, single: () => Promise<RpcResponse<T>>): Promise<RpcResponse<T>> {\n" +
+		"  return new Promise((resolve, reject) => {\n" +
+		"    let queue = batchQueues.get(client);\n" +
+		"    if (!queue) {\n" +
+		"      queue = [];\n" +
+		"      batchQueues.set(client, queue);\n" +
+		"    }\n" +
+		"    queue.push({ call: callFrame(method, path, input, headers), single, resolve, reject });\n" +
+		"    if (queue.length === maxBatchSize) {\n" +
+		"      // the full batch is sent right away and the next calls start a new one\n" +
+		"      batchQueues.delete(client);\n" +
+		"      flushBatch(client, queue);\n" +
+		"    } else if (queue.length === 1) {\n" +
+		"      const pending = queue;\n" +
+		"      setTimeout(() => {\n" +
+		"        if (batchQueues.get(client) === pending) {\n" +
+		"          batchQueues.delete(client);\n" +
+		"          flushBatch(client, pending);\n" +
+		"        }\n" +
+		"      }, 0);\n" +
+		"    }\n" +
+		"  });\n" +
+		"}\n" +
+		"async function flushBatch(client: RpcClientOptions, entries: BatchEntry[]) {\n" +
+		"  if (entries.length === 1) {\n" +
+		"    entries[0].single().then(entries[0].resolve, entries[0].reject);\n" +
+		"    return;\n" +
+		"  }\n" +
+		"  const { signal, done } = requestSignal(client, client.timeout);\n" +
+		"  try {\n" +
+		"    const init: RequestInit = {\n" +
+		"      method: 'POST',\n" +
+		"      headers: await requestHeaders(client, { 'Content-Type': 'application/json' }),\n" +
+		"      credentials: client.credentials,\n" +
+		"      signal: signal,\n" +
+		"      body: stringifyJSON(entries.map((entry) => entry.call)),\n" +
+		"    };\n" +
+		"    const res = await rpcSend(client, { url: (client.baseUrl ?? host) + batchPath, init });\n" +
+		"    if (!res.ok) {\n" +
+		"      throw new Error(`batch request failed with status ${res.status}`);\n" +
+		"    }\n" +
+		"    const results: BatchResult[] = await res.json();\n" +
+		"    entries.forEach((entry, i) => {\n" +
+		"      const result = results[i];\n" +
+		"      entry.resolve(rpcResponse(result.status, new Headers(result.headers), result.body));\n" +
+		"    });\n" +
+		"  } catch (err) {\n" +
+		"    entries.forEach((entry) => entry.reject(err));\n" +
+		"  } finally {\n" +
+		"    done();\n" +
 		"  }\n" +
-		"\n" +
-		"  return { \n" +
-		"    body: body as T, \n" +
-		"    status: res.status, \n" +
-		"    headers: res.headers \n" +
-		"  };\n" +
 		"}\n"
 	builder.WriteString(text)
 }
@@ -188,32 +188,43 @@ func (r *Router) PrintInfo() {
 	for slug, procInfo := range r.procedures {
 
 		pathAndMethod := fmt.Sprintf("	%s : %s ", procInfo.method, slug)
+		if procInfo.method == MUTATION && procInfo.httpMethod() != http.MethodPost {
+			pathAndMethod = fmt.Sprintf("	%s (%s) : %s ", procInfo.method, procInfo.httpMethod(), slug)
+		}
 		inputsAndOutputs := &strings.Builder{}
 
 		inputsAndOutputs.WriteString("(")
+		types := newInlineTSTypeRegistry(r.app)
+		procedure := types.models.procedure(procInfo, r.getAbsPath()+slug)
+		queryType := "any"
+		if procedure.query != nil || len(procedure.slugs) > 0 {
+			queryType = types.queryObject(procedure.query, procedure.slugs)
+		}
 
 		switch procInfo.method {
-		case QUERY:
-			queryType := getType(procInfo.querySchema)
-			inputsAndOutputs.WriteString(goToTsObj(queryType))
+		case QUERY, SUBSCRIPTION:
+			inputsAndOutputs.WriteString(queryType)
 		case MUTATION:
 			inputsAndOutputs.WriteString("{")
 			inputsAndOutputs.WriteString("query:")
-			queryType := getType(procInfo.querySchema)
-			inputsAndOutputs.WriteString(goToTsObj(queryType))
+			inputsAndOutputs.WriteString(queryType)
 			inputsAndOutputs.WriteString(",")
-			inputType := getType(procInfo.inputSchema)
 			inputsAndOutputs.WriteString("input:")
-			inputsAndOutputs.WriteString(goToTsObj(inputType))
+			inputsAndOutputs.WriteString(types.tsType(procedure.input))
 			inputsAndOutputs.WriteString("}")
 		}
-		if procInfo.method == QUERY || procInfo.method == MUTATION {
+		if procInfo.method == QUERY || procInfo.method == MUTATION || procInfo.method == SUBSCRIPTION {
 			inputsAndOutputs.WriteString(")=>")
-			outputType := getType(procInfo.outputSchema)
-			inputsAndOutputs.WriteString(goTypeToTSType(outputType))
+			inputsAndOutputs.WriteString(types.tsType(procedure.output))
 		}
 
 		fmt.Println(pathAndMethod + inputsAndOutputs.String())
+		for _, line := range procInfo.docs.lines() {
+			if strings.HasPrefix(line, "@deprecated") {
+				line = DefaultColors.Red + line + DefaultColors.Reset
+			}
+			fmt.Println("\t\t" + line)
+		}
 	}
 
 	for _, nestedRoute := range r.routes {
@@ -221,19 +232,18 @@ func (r *Router) PrintInfo() {
 	}
 }
 
-func createCtx(w http.ResponseWriter, r *http.Request) *Ctx {
+func createCtx(w http.ResponseWriter, r *http.Request, app *App) *Ctx {
 	return &Ctx{
 		httpW: w,
 		httpR: r,
+		app:   app,
 	}
 
 }
-func methodsMatch(httpMethod string, bluerpcMethod Method) bool {
-	switch bluerpcMethod {
-	case QUERY:
-		return httpMethod == "GET"
-	case MUTATION:
-		return httpMethod == "POST"
+func methodsMatch(httpMethod string, proc *ProcedureInfo) bool {
+	switch proc.method {
+	case QUERY, SUBSCRIPTION, MUTATION:
+		return httpMethod == proc.httpMethod()
 	}
 	return false
 }
@@ -0,0 +1,248 @@
+package bluerpc
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Schema is the language neutral description of the procedures of an app and of the types that they use.
+// It is what a Generator receives, so that clients in other languages (Dart, C#, a GraphQL SDL...) can be written without reflecting on the go types.
+// It is put in json as it is, which makes it a snapshot of the API that can be committed
+type Schema struct {
+	// how the int64 and uint64 values are put on the wire, as json numbers or as json strings
+	Int64Mode Int64Mode `json:"int64Mode"`
+	// every procedure of the app, the procedures of a router before its sub routers. Static routes are left out
+	Procedures []SchemaProcedure `json:"procedures"`
+	// the structs and the registered enums that the procedures use, in the order they are first used. Their names are unique within the schema
+	Types []SchemaType `json:"types"`
+}
+
+// SchemaProcedure describes a procedure attached to the app
+type SchemaProcedure struct {
+	Path   string `json:"path"`
+	Method Method `json:"method"`
+	// the http method the procedure is served on, GET for queries and subscriptions and POST, PUT, PATCH or DELETE for mutations
+	HTTPMethod string `json:"httpMethod,omitempty"`
+
+	// the query parameters, nil if the procedure takes none
+	Query *TypeRef `json:"query,omitempty"`
+	// the body of a mutation, nil if the procedure takes none
+	Input *TypeRef `json:"input,omitempty"`
+	// the response body, or the data of every event of a subscription. nil if the procedure returns anything
+	Output *TypeRef `json:"output,omitempty"`
+	// the dynamic slugs of the path that no query field fills, clients take them as string parameters
+	Slugs []string `json:"slugs,omitempty"`
+
+	// the procedure goes through its authorizer before being called
+	Protected bool `json:"protected,omitempty"`
+	// the errors declared with Throws
+	Errors []SchemaError `json:"errors,omitempty"`
+
+	Summary         string   `json:"summary,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Deprecated      bool     `json:"deprecated,omitempty"`
+	DeprecatedSince string   `json:"deprecatedSince,omitempty"`
+}
+
+// SchemaError is an error that a procedure declares with Throws
+type SchemaError struct {
+	Code int `json:"code"`
+	// the type of the data of the error, nil if it has none
+	Data *TypeRef `json:"data,omitempty"`
+}
+
+// TypeKind is the kind of a value on the wire
+type TypeKind string
+
+const (
+	// any json value
+	TypeAny    TypeKind = "any"
+	TypeString TypeKind = "string"
+	TypeBool   TypeKind = "bool"
+	TypeInt    TypeKind = "int"
+	// int64 and uint64 values, sent as json strings unless Schema.Int64Mode is Int64AsNumber
+	TypeInt64 TypeKind = "int64"
+	TypeFloat TypeKind = "float"
+	// time.Time values, sent as RFC 3339 strings
+	TypeTime TypeKind = "time"
+	// a value that can be null, of the type Elem
+	TypeOptional TypeKind = "optional"
+	// a json array of Elem
+	TypeList TypeKind = "list"
+	// a json object with string keys and values of the type Elem
+	TypeMap TypeKind = "map"
+	// a struct declared in Schema.Types
+	TypeObject TypeKind = "object"
+	// an enum declared in Schema.Types
+	TypeEnum TypeKind = "enum"
+)
+
+// TypeRef is the type of a field, or of the query, input and output of a procedure
+type TypeRef struct {
+	Kind TypeKind `json:"kind"`
+	// the type of the values of optionals, lists and maps
+	Elem *TypeRef `json:"elem,omitempty"`
+	// the name of the object or of the enum in Schema.Types
+	Name string `json:"name,omitempty"`
+	// the go type of the object or of the enum, with the path of its package. The names are given in the order the types are first used
+	// and can change when a procedure is added, the go type identifies the type from one snapshot to the next
+	GoType string `json:"goType,omitempty"`
+}
+
+// SchemaType is a named type : a struct or an enum registered with RegisterEnum
+type SchemaType struct {
+	Name string `json:"name"`
+	// TypeObject or TypeEnum
+	Kind TypeKind `json:"kind"`
+	// the go type it was built from, with the path of its package
+	GoType string `json:"goType"`
+
+	// the fields of a struct, in the order they are put on the wire
+	Fields []SchemaField `json:"fields,omitempty"`
+
+	// the values of an enum the way they are put on the wire, and their kind (string, int, float or bool)
+	Values    []any    `json:"values,omitempty"`
+	ValueKind TypeKind `json:"valueKind,omitempty"`
+}
+
+// SchemaField is a field of a struct, named the way it is put on the wire
+type SchemaField struct {
+	Name     string  `json:"name"`
+	Type     TypeRef `json:"type"`
+	Required bool    `json:"required,omitempty"`
+	// the doc tag of the field
+	Doc string `json:"doc,omitempty"`
+	// the field of a query fills a dynamic slug of the path rather than a query parameter
+	Slug bool `json:"slug,omitempty"`
+	// the rules of the validate tag, in their order
+	Validate []ValidationRule `json:"validate,omitempty"`
+	// the values that the enum tag of the field allows
+	Enum []string `json:"enum,omitempty"`
+}
+
+// ValidationRule is a rule of a validate tag, min=3 for example
+type ValidationRule struct {
+	Name  string `json:"name"`
+	Param string `json:"param,omitempty"`
+}
+
+// Generator writes a file from the schema of an app. Register it with App.AddGenerator so that it is written along with the other clients
+type Generator interface {
+	Generate(schema *Schema) ([]byte, error)
+}
+
+// GeneratorFunc lets a function be used as a Generator
+type GeneratorFunc func(schema *Schema) ([]byte, error)
+
+func (fn GeneratorFunc) Generate(schema *Schema) ([]byte, error) {
+	return fn(schema)
+}
+
+// a generator added with AddGenerator and the file it writes
+type pluginGenerator struct {
+	outputPath string
+	generator  Generator
+}
+
+// AddGenerator writes the output of the generator to outputPath whenever the clients are written : when the app starts listening,
+// with WriteClients or with bluerpc gen. CheckClients compares it with the file on disk like the other clients
+func (a *App) AddGenerator(outputPath string, generator Generator) {
+	a.generators = append(a.generators, pluginGenerator{outputPath: outputPath, generator: generator})
+}
+
+// Schema returns the description of every procedure of the app and of the types that they use, the same models that the python, swift and kotlin clients are generated from
+func (a *App) Schema() *Schema {
+	schema := &Schema{Int64Mode: a.config.Int64Mode, Procedures: []SchemaProcedure{}, Types: []SchemaType{}}
+	if schema.Int64Mode == "" {
+		schema.Int64Mode = Int64AsNumber
+	}
+
+	models := newTypeModels(a)
+	a.walkProcedures(func(fullPath string, proc *ProcedureInfo) {
+		procedure := models.procedure(proc, fullPath)
+		schemaProcedure := SchemaProcedure{
+			Path:            procedure.path,
+			Method:          procedure.method,
+			HTTPMethod:      procedure.httpMethod,
+			Query:           procedure.query.ref(),
+			Input:           procedure.input.ref(),
+			Output:          procedure.output.ref(),
+			Slugs:           procedure.slugs,
+			Protected:       proc.protected,
+			Summary:         proc.docs.summary,
+			Description:     proc.docs.description,
+			Tags:            proc.docs.tags,
+			Deprecated:      proc.docs.deprecated,
+			DeprecatedSince: proc.docs.deprecatedSince,
+		}
+		for _, procErr := range procedure.errors {
+			schemaProcedure.Errors = append(schemaProcedure.Errors, SchemaError{Code: procErr.code, Data: procErr.data.ref()})
+		}
+		schema.Procedures = append(schema.Procedures, schemaProcedure)
+	})
+
+	values := newJSONSchemaBuilder(a, "")
+	for _, decl := range models.decls {
+		schemaType := SchemaType{Name: decl.name, GoType: goTypeName(decl.goType)}
+		if decl.goType.Kind() != reflect.Struct {
+			schemaType.Kind = TypeEnum
+			schemaType.ValueKind = (&typeModel{kind: decl.baseKind}).ref().Kind
+			for _, value := range decl.values {
+				schemaType.Values = append(schemaType.Values, values.wireValue(reflect.ValueOf(value)))
+			}
+			schema.Types = append(schema.Types, schemaType)
+			continue
+		}
+		schemaType.Kind = TypeObject
+		for _, field := range decl.fields {
+			schemaType.Fields = append(schemaType.Fields, SchemaField{
+				Name:     field.name,
+				Type:     *field.model.ref(),
+				Required: field.required,
+				Doc:      field.doc,
+				Slug:     field.slug,
+				Validate: field.validate,
+				Enum:     field.enum,
+			})
+		}
+		schema.Types = append(schema.Types, schemaType)
+	}
+	return schema
+}
+
+// returns the exported form of a model, nil for a nil model
+func (model *typeModel) ref() *TypeRef {
+	if model == nil {
+		return nil
+	}
+	ref := &TypeRef{Kind: schemaTypeKinds[model.kind], Elem: model.elem.ref()}
+	if model.decl != nil {
+		ref.Name = model.decl.name
+		ref.GoType = goTypeName(model.decl.goType)
+	}
+	return ref
+}
+
+var schemaTypeKinds = map[typeKind]TypeKind{kindAny: TypeAny, kindString: TypeString, kindBool: TypeBool, kindInt: TypeInt, kindInt64: TypeInt64, kindFloat: TypeFloat,
+	kindTime: TypeTime, kindOptional: TypeOptional, kindList: TypeList, kindMap: TypeMap, kindObject: TypeObject, kindEnum: TypeEnum}
+
+// splits a validate tag into its rules
+func parseValidateTag(tag string) []ValidationRule {
+	var rules []ValidationRule
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name != "" {
+			rules = append(rules, ValidationRule{Name: name, Param: param})
+		}
+	}
+	return rules
+}
+
+// returns the name of a go type with the path of its package, github.com/acme/api.User for example
+func goTypeName(t reflect.Type) string {
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
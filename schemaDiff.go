@@ -0,0 +1,272 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// BreakingChange is a change between two schemas that breaks the clients generated from the older one
+type BreakingChange struct {
+	// the path of the procedure that changed, empty when the change concerns every procedure
+	Procedure string `json:"procedure,omitempty"`
+	// where the change is, input.members[].name for example. Empty when the change is about the procedure itself
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (change BreakingChange) String() string {
+	if change.Procedure == "" {
+		return change.Message
+	}
+	if change.Location == "" {
+		return change.Procedure + " : " + change.Message
+	}
+	return change.Procedure + " " + change.Location + " : " + change.Message
+}
+
+// the side of the wire that a type is on. The server reads what the clients send and the clients read what the server sends,
+// so the changes that break them are not the same
+type wireDirection int
+
+const (
+	// queries and inputs, sent by the clients
+	toServer wireDirection = iota
+	// outputs and the events of subscriptions, sent by the server
+	toClient
+)
+
+// CompareSchemas returns the changes from previous to current that break the clients generated from previous, in the order of the procedures of previous :
+// removed procedures, procedures served on another http method, procedures that became protected, query and input fields that became required, fields whose type changed,
+// output fields that were removed or that are no longer always sent, enum values that clients send and were removed and enum values that clients receive and were added.
+// The types are paired through the procedures and the fields that refer to them rather than by their names, which can change from one snapshot to the next (see TypeRef.GoType)
+func CompareSchemas(previous *Schema, current *Schema) []BreakingChange {
+	currentProcedures := map[string]SchemaProcedure{}
+	for _, procedure := range current.Procedures {
+		currentProcedures[procedure.Path] = procedure
+	}
+
+	changes := []BreakingChange{}
+	for _, before := range previous.Procedures {
+		after, ok := currentProcedures[before.Path]
+		switch {
+		case !ok:
+			changes = append(changes, BreakingChange{Procedure: before.Path, Message: fmt.Sprintf("the %s was removed", before.Method)})
+			continue
+		case before.Method != after.Method:
+			changes = append(changes, BreakingChange{Procedure: before.Path, Message: fmt.Sprintf("the %s became a %s", before.Method, after.Method)})
+			continue
+		}
+		// the schemas written before HTTPMethod existed do not have it
+		if before.HTTPMethod != "" && after.HTTPMethod != "" && before.HTTPMethod != after.HTTPMethod {
+			changes = append(changes, BreakingChange{Procedure: before.Path, Message: fmt.Sprintf("is served on %s instead of %s", after.HTTPMethod, before.HTTPMethod)})
+		}
+		if !before.Protected && after.Protected {
+			changes = append(changes, BreakingChange{Procedure: before.Path, Message: "the procedure became protected"})
+		}
+		comparison := &schemaComparison{previous: previous, current: current, procedure: before.Path, compared: map[comparedObjects]bool{}}
+		comparison.compareBody("query", before.Query, after.Query, toServer)
+		comparison.compareBody("input", before.Input, after.Input, toServer)
+		comparison.compareBody("output", before.Output, after.Output, toClient)
+		changes = append(changes, comparison.changes...)
+	}
+	if previous.Int64Mode != current.Int64Mode && (previous.Int64Mode == Int64AsNumber || current.Int64Mode == Int64AsNumber) {
+		changes = append(changes, BreakingChange{Message: fmt.Sprintf("int64 values are sent as %s instead of %s", current.Int64Mode, previous.Int64Mode)})
+	}
+	return changes
+}
+
+// ReadSchemaFile reads a schema written with SchemaOutputPath, in order to compare it with CompareSchemas
+func ReadSchemaFile(path string) (*Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := &Schema{}
+	if err := json.Unmarshal(content, schema); err != nil {
+		return nil, fmt.Errorf("%s is not a bluerpc schema : %w", path, err)
+	}
+	return schema, nil
+}
+
+// the comparison of the types of a procedure in two schemas
+type schemaComparison struct {
+	previous, current *Schema
+	procedure         string
+	changes           []BreakingChange
+	// the pairs of objects that were already compared, recursive types would be compared forever otherwise
+	compared map[comparedObjects]bool
+}
+
+type comparedObjects struct {
+	before, after string
+	direction     wireDirection
+}
+
+func (c *schemaComparison) report(location string, message string, args ...any) {
+	c.changes = append(c.changes, BreakingChange{Procedure: c.procedure, Location: location, Message: fmt.Sprintf(message, args...)})
+}
+
+// compares a query, an input or an output. A query or an input that appears is compared as an object that had no fields,
+// the older clients can not send an input that is not an object (a list for example) at all
+func (c *schemaComparison) compareBody(location string, before *TypeRef, after *TypeRef, direction wireDirection) {
+	switch {
+	case before == nil && after == nil:
+	case after == nil:
+		if direction == toClient {
+			c.report(location, "is no longer sent")
+		}
+	case before == nil:
+		if direction != toServer {
+			return
+		}
+		switch unwrapOptional(after).Kind {
+		case TypeObject:
+			c.compareObjects(location, &SchemaType{}, c.findType(c.current, unwrapOptional(after)), direction)
+		case TypeAny:
+		default:
+			c.report(location, "is now required")
+		}
+	default:
+		c.compareTypes(location, before, after, direction)
+	}
+}
+
+func (c *schemaComparison) compareTypes(location string, before *TypeRef, after *TypeRef, direction wireDirection) {
+	// a value that can be null is told apart by the required fields, not by its type
+	before, after = unwrapOptional(before), unwrapOptional(after)
+	if before.Kind == TypeAny || (after.Kind == TypeAny && direction == toServer) {
+		return
+	}
+	if wireKind(before, c.previous) != wireKind(after, c.current) {
+		c.report(location, "changed type from %s to %s", describeTypeRef(before), describeTypeRef(after))
+		return
+	}
+	switch before.Kind {
+	case TypeList:
+		c.compareTypes(location+"[]", before.Elem, after.Elem, direction)
+	case TypeMap:
+		c.compareTypes(location+"{}", before.Elem, after.Elem, direction)
+	case TypeEnum:
+		c.compareEnums(location, c.findType(c.previous, before), c.findType(c.current, after), direction)
+	case TypeObject:
+		key := comparedObjects{before: before.Name, after: after.Name, direction: direction}
+		if c.compared[key] {
+			return
+		}
+		c.compared[key] = true
+		c.compareObjects(location, c.findType(c.previous, before), c.findType(c.current, after), direction)
+	}
+}
+
+func (c *schemaComparison) compareObjects(location string, before *SchemaType, after *SchemaType, direction wireDirection) {
+	beforeFields := map[string]SchemaField{}
+	for _, field := range before.Fields {
+		beforeFields[field.Name] = field
+	}
+	afterFields := map[string]SchemaField{}
+	for _, field := range after.Fields {
+		afterFields[field.Name] = field
+	}
+
+	for _, field := range after.Fields {
+		previousField, existed := beforeFields[field.Name]
+		if direction == toServer && field.isValidatedRequired() && (!existed || !previousField.isValidatedRequired()) {
+			c.report(joinLocation(location, field.Name), "is now required")
+		}
+	}
+	for _, field := range before.Fields {
+		fieldLocation := joinLocation(location, field.Name)
+		currentField, exists := afterFields[field.Name]
+		switch {
+		case !exists:
+			if direction == toClient {
+				c.report(fieldLocation, "was removed")
+			}
+			continue
+		case direction == toClient && field.Required && !currentField.Required:
+			c.report(fieldLocation, "is no longer required, it can be missing")
+		}
+		c.compareTypes(fieldLocation, &field.Type, &currentField.Type, direction)
+	}
+}
+
+// the clients can send the values that were removed and they can not read the values that were added
+func (c *schemaComparison) compareEnums(location string, before *SchemaType, after *SchemaType, direction wireDirection) {
+	from, to := before, after
+	message := "no longer accepts %v"
+	if direction == toClient {
+		from, to = after, before
+		message = "can now be %v"
+	}
+	for _, value := range from.Values {
+		found := false
+		for _, other := range to.Values {
+			if fmt.Sprint(value) == fmt.Sprint(other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.report(location, message, value)
+		}
+	}
+}
+
+// returns the type of the schema that the reference points to, an empty type if there is none. The name is only looked up in the schema of the reference,
+// the types of two schemas are paired by the positions that refer to them
+func (c *schemaComparison) findType(schema *Schema, ref *TypeRef) *SchemaType {
+	for i := range schema.Types {
+		schemaType := &schema.Types[i]
+		if schemaType.Name == ref.Name && (ref.GoType == "" || schemaType.GoType == "" || schemaType.GoType == ref.GoType) {
+			return schemaType
+		}
+	}
+	return &SchemaType{Name: ref.Name, GoType: ref.GoType}
+}
+
+// returns the kind of the value on the wire. int64 values are json numbers like the other integers unless the schema sends them as strings
+func wireKind(ref *TypeRef, schema *Schema) TypeKind {
+	if ref.Kind == TypeInt64 && schema.Int64Mode == Int64AsNumber {
+		return TypeInt
+	}
+	return ref.Kind
+}
+
+func unwrapOptional(ref *TypeRef) *TypeRef {
+	for ref.Kind == TypeOptional && ref.Elem != nil {
+		ref = ref.Elem
+	}
+	return ref
+}
+
+func describeTypeRef(ref *TypeRef) string {
+	switch ref.Kind {
+	case TypeObject, TypeEnum:
+		return ref.Name
+	case TypeList:
+		return describeTypeRef(unwrapOptional(ref.Elem)) + "[]"
+	case TypeMap:
+		return "map of " + describeTypeRef(unwrapOptional(ref.Elem))
+	default:
+		return string(ref.Kind)
+	}
+}
+
+func joinLocation(location string, field string) string {
+	return location + "." + field
+}
+
+// reports if the server rejects the calls that leave the field out : the slugs of the path and the fields with a required validation rule.
+// Required only tells that the field is always put on the wire, an older client can leave out a field that the server does not validate
+func (field SchemaField) isValidatedRequired() bool {
+	if field.Slug {
+		return true
+	}
+	for _, rule := range field.Validate {
+		if rule.Name == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type schema_diff_test_status string
+
+type schema_diff_test_input_v1 struct {
+	Name string `json:"name" validate:"required"`
+	Note string `json:"note"`
+}
+type schema_diff_test_input_v2 struct {
+	Name  string `json:"name" validate:"required"`
+	Note  string `json:"note" validate:"required"`
+	Email string `json:"email"`
+}
+type schema_diff_test_output_v1 struct {
+	Id     int                     `json:"id" validate:"required"`
+	Name   string                  `json:"name"`
+	Status schema_diff_test_status `json:"status"`
+}
+type schema_diff_test_output_v2 struct {
+	Id     string                  `json:"id" validate:"required"`
+	Status schema_diff_test_status `json:"status"`
+	Extra  string                  `json:"extra"`
+}
+
+func TestCompareSchemas(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING COMPARE SCHEMAS" + DefaultColors.Reset)
+	newApp := func() *App {
+		return New(&Config{
+			DisableGenerateTS:   true,
+			DisableInfoPrinting: true,
+			Authorizer: NewAuth(func(ctx *Ctx) (any, error) {
+				return nil, nil
+			}),
+		})
+	}
+
+	previousApp := newApp()
+	RegisterEnum(previousApp, schema_diff_test_status("active"))
+	NewMutation(previousApp, func(ctx *Ctx, query any, input schema_diff_test_input_v1) (*Res[schema_diff_test_output_v1], error) {
+		return nil, nil
+	}).Attach(previousApp, "/users/create")
+	NewQuery(previousApp, func(ctx *Ctx, query any) (*Res[schema_diff_test_output_v1], error) {
+		return nil, nil
+	}).Attach(previousApp, "/users/list")
+	NewQuery(previousApp, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, nil
+	}).Attach(previousApp, "/users/old")
+	NewMutation(previousApp, func(ctx *Ctx, query any, input any) (*Res[any], error) {
+		return nil, nil
+	}).Attach(previousApp, "/users/tags")
+	previous := previousApp.Schema()
+
+	if changes := CompareSchemas(previous, previousApp.Schema()); len(changes) != 0 {
+		t.Fatalf(DefaultColors.Red+"Expected no changes between identical schemas, got %v", changes)
+	}
+
+	currentApp := newApp()
+	RegisterEnum(currentApp, schema_diff_test_status("active"), schema_diff_test_status("banned"))
+	NewMutation(currentApp, func(ctx *Ctx, query any, input schema_diff_test_input_v2) (*Res[schema_diff_test_output_v2], error) {
+		return nil, nil
+	}).HTTPMethod("PUT").Attach(currentApp, "/users/create")
+	NewQuery(currentApp, func(ctx *Ctx, query any) (*Res[schema_diff_test_output_v1], error) {
+		return nil, nil
+	}).Protected().Attach(currentApp, "/users/list")
+	NewQuery(currentApp, func(ctx *Ctx, query any) (*Res[any], error) {
+		return nil, nil
+	}).Attach(currentApp, "/users/new")
+	NewMutation(currentApp, func(ctx *Ctx, query any, input []string) (*Res[any], error) {
+		return nil, nil
+	}).Attach(currentApp, "/users/tags")
+	current := currentApp.Schema()
+
+	var reported []string
+	for _, change := range CompareSchemas(previous, current) {
+		reported = append(reported, change.String())
+	}
+	expected := []string{
+		"/users/create : is served on PUT instead of POST",
+		"/users/create input.note : is now required",
+		"/users/create output.id : changed type from int to string",
+		"/users/create output.name : was removed",
+		"/users/create output.status : can now be banned",
+		"/users/list : the procedure became protected",
+		"/users/list output.status : can now be banned",
+		"/users/old : the query was removed",
+		"/users/tags input : is now required",
+	}
+	if strings.Join(reported, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf(DefaultColors.Red+"Expected the breaking changes\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(reported, "\n"))
+	}
+
+	// the older schema can be read back from the file it was written to
+	path := filepath.Join(t.TempDir(), "schema.json")
+	encoded, _ := json.MarshalIndent(previous, "", "  ")
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not write the schema : %s", err.Error())
+	}
+	read, err := ReadSchemaFile(path)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not read the schema : %s", err.Error())
+	}
+	if changes := CompareSchemas(read, current); len(changes) != len(expected) {
+		t.Fatalf(DefaultColors.Red+"Expected the same changes from the schema read back, got %v", changes)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED COMPARE SCHEMAS" + DefaultColors.Reset)
+}
+
+type schema_diff_test_member struct {
+	Name string `json:"name" paramName:"memberName"`
+	Age  int    `json:"age" paramName:"memberAge"`
+}
+
+func TestCompareSchemasRenamedTypes(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING COMPARE SCHEMAS WITH RENAMED TYPES" + DefaultColors.Reset)
+	newApp := func() *App {
+		return New(&Config{DisableGenerateTS: true, DisableInfoPrinting: true})
+	}
+
+	previousApp := newApp()
+	NewQuery(previousApp, func(ctx *Ctx, query any) (*Res[schema_diff_test_member], error) {
+		return nil, nil
+	}).Attach(previousApp, "/members")
+	previous := previousApp.Schema()
+
+	// the query that is walked first takes the name of the type, the output is declared under another name
+	currentApp := newApp()
+	NewQuery(currentApp, func(ctx *Ctx, query schema_diff_test_member) (*Res[any], error) {
+		return nil, nil
+	}).Attach(currentApp, "/find")
+	NewQuery(currentApp, func(ctx *Ctx, query any) (*Res[schema_diff_test_member], error) {
+		return nil, nil
+	}).Attach(currentApp, "/members")
+	current := currentApp.Schema()
+
+	before, after := previous.Procedures[0].Output, current.Procedures[1].Output
+	if before.Name == after.Name {
+		t.Fatalf(DefaultColors.Red+"Expected the output to be declared under another name, got %s twice", before.Name)
+	}
+	if before.GoType != after.GoType || after.GoType != goTypeName(reflect.TypeOf(schema_diff_test_member{})) {
+		t.Fatalf(DefaultColors.Red+"Expected the output to keep its go type, got %s and %s", before.GoType, after.GoType)
+	}
+	if changes := CompareSchemas(previous, current); len(changes) != 0 {
+		t.Fatalf(DefaultColors.Red+"Expected no changes when a type is renamed, got %v", changes)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED COMPARE SCHEMAS WITH RENAMED TYPES" + DefaultColors.Reset)
+}
+
+type schema_diff_test_counter_v1 struct {
+	Count int `json:"count"`
+}
+type schema_diff_test_counter_v2 struct {
+	Count int64 `json:"count"`
+}
+
+func TestCompareSchemasInt64Mode(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING COMPARE SCHEMAS INT64 MODE" + DefaultColors.Reset)
+	counterSchema := func(mode Int64Mode, counter func(app *App)) *Schema {
+		app := New(&Config{DisableGenerateTS: true, DisableInfoPrinting: true, Int64Mode: mode})
+		counter(app)
+		return app.Schema()
+	}
+	v1 := func(app *App) {
+		NewQuery(app, func(ctx *Ctx, query any) (*Res[schema_diff_test_counter_v1], error) {
+			return nil, nil
+		}).Attach(app, "/counter")
+	}
+	v2 := func(app *App) {
+		NewQuery(app, func(ctx *Ctx, query any) (*Res[schema_diff_test_counter_v2], error) {
+			return nil, nil
+		}).Attach(app, "/counter")
+	}
+
+	// int64 values are json numbers like the other integers
+	if changes := CompareSchemas(counterSchema(Int64AsNumber, v1), counterSchema(Int64AsNumber, v2)); len(changes) != 0 {
+		t.Fatalf(DefaultColors.Red+"Expected no changes from int to int64 sent as numbers, got %v", changes)
+	}
+	if changes := CompareSchemas(counterSchema(Int64AsNumber, v2), counterSchema(Int64AsNumber, v1)); len(changes) != 0 {
+		t.Fatalf(DefaultColors.Red+"Expected no changes from int64 sent as numbers to int, got %v", changes)
+	}
+
+	var reported []string
+	for _, change := range CompareSchemas(counterSchema(Int64AsNumber, v1), counterSchema(Int64AsString, v2)) {
+		reported = append(reported, change.String())
+	}
+	expected := []string{
+		"/counter output.count : changed type from int to int64",
+		"int64 values are sent as string instead of number",
+	}
+	if strings.Join(reported, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf(DefaultColors.Red+"Expected the breaking changes\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(reported, "\n"))
+	}
+	fmt.Println(DefaultColors.Green + "PASSED COMPARE SCHEMAS INT64 MODE" + DefaultColors.Reset)
+}
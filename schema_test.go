@@ -0,0 +1,178 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schema_test_mapped struct {
+	Created time.Time          `json:"created"`
+	Data    []byte             `json:"data"`
+	Raw     json.RawMessage    `json:"raw"`
+	Id      tsgen_test_id      `json:"id"`
+	Amount  int64              `json:"amount"`
+	Price   tsgen_test_decimal `json:"price"`
+	// arrays of bytes are not base64 strings, only slices are
+	Sum [4]byte `json:"sum"`
+}
+
+func TestSchema(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING SCHEMA" + DefaultColors.Reset)
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		Int64Mode:           Int64AsString,
+		Authorizer: NewAuth(func(ctx *Ctx) (any, error) {
+			return nil, nil
+		}),
+	})
+	RegisterEnum(app, tsgen_test_active, tsgen_test_disabled)
+	teams := app.Router("/teams")
+	NewQuery(app, func(ctx *Ctx, query client_test_query) (*Res[client_test_team], error) {
+		return nil, nil
+	}).Protected().Attach(teams, "/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_member) (*Res[any], error) {
+		return nil, nil
+	}).Throws(404, openapi_test_input{}).Summary("Adds a member").Deprecated("v2").Attach(teams, "/{team}/members")
+
+	schema := app.Schema()
+	if schema.Int64Mode != Int64AsString || len(schema.Procedures) != 2 {
+		t.Fatalf(DefaultColors.Red+"Expected 2 procedures in the string int64 mode, got %+v", schema)
+	}
+
+	byId := schema.Procedures[0]
+	if byId.Path != "/teams/{id}" || byId.Method != QUERY || !byId.Protected || len(byId.Slugs) != 0 {
+		t.Fatalf(DefaultColors.Red+"Unexpected /teams/{id} procedure : %+v", byId)
+	}
+	if byId.Query.Kind != TypeObject || byId.Query.Name != "ClientTestQuery" || byId.Output.Name != "ClientTestTeam" {
+		t.Fatalf(DefaultColors.Red+"Expected /teams/{id} to refer to its query and output types, got %+v %+v", byId.Query, byId.Output)
+	}
+
+	members := schema.Procedures[1]
+	if members.Protected || members.Input.Name != "TsgenTestMember" || members.Output != nil || len(members.Slugs) != 1 || members.Slugs[0] != "team" {
+		t.Fatalf(DefaultColors.Red+"Unexpected /teams/{team}/members procedure : %+v", members)
+	}
+	if members.Summary != "Adds a member" || !members.Deprecated || members.DeprecatedSince != "v2" {
+		t.Fatalf(DefaultColors.Red+"Expected the docs of /teams/{team}/members, got %+v", members)
+	}
+	if len(members.Errors) != 1 || members.Errors[0].Code != 404 || members.Errors[0].Data.Name != "OpenapiTestInput" {
+		t.Fatalf(DefaultColors.Red+"Expected the declared 404 error, got %+v", members.Errors)
+	}
+
+	types := map[string]SchemaType{}
+	for _, schemaType := range schema.Types {
+		types[schemaType.Name] = schemaType
+	}
+	query := types["ClientTestQuery"]
+	if len(query.Fields) != 2 || !query.Fields[0].Slug || !query.Fields[0].Required || query.Fields[0].Type.Kind != TypeInt64 {
+		t.Fatalf(DefaultColors.Red+"Expected id to be a required int64 slug, got %+v", query.Fields)
+	}
+	team := types["ClientTestTeam"]
+	if owner := team.Fields[3]; owner.Name != "owner" || owner.Type.Kind != TypeOptional || owner.Type.Elem.Name != "ClientTestMember" {
+		t.Fatalf(DefaultColors.Red+"Expected owner to be an optional ClientTestMember, got %+v", owner)
+	}
+	member := types["TsgenTestMember"]
+	if status := member.Fields[0]; status.Type.Kind != TypeEnum || status.Type.Name != "TsgenTestStatus" || !status.Required {
+		t.Fatalf(DefaultColors.Red+"Expected status to be the required TsgenTestStatus enum, got %+v", status)
+	}
+	if role := member.Fields[1]; len(role.Enum) != 2 || role.Enum[0] != "admin" {
+		t.Fatalf(DefaultColors.Red+"Expected the values of the enum tag of role, got %+v", role)
+	}
+	if status := types["TsgenTestStatus"]; status.Kind != TypeEnum || status.ValueKind != TypeString || len(status.Values) != 2 || status.Values[1] != "disabled" {
+		t.Fatalf(DefaultColors.Red+"Expected the values of TsgenTestStatus, got %+v", status)
+	}
+	input := types["OpenapiTestInput"]
+	if name := input.Fields[0]; len(name.Validate) != 2 || name.Validate[1] != (ValidationRule{Name: "min", Param: "2"}) {
+		t.Fatalf(DefaultColors.Red+"Expected the validate rules of name, got %+v", name.Validate)
+	}
+	if !strings.HasSuffix(input.GoType, "bluerpc.openapi_test_input") {
+		t.Fatalf(DefaultColors.Red+"Expected the go type of OpenapiTestInput, got %s", input.GoType)
+	}
+
+	// the types that encode themselves are mapped the same way in every generator, pointers to error data are not optional
+	mappedApp := New(&Config{DisableGenerateTS: true, DisableInfoPrinting: true, Int64Mode: Int64AsString})
+	NewQuery(mappedApp, func(ctx *Ctx, query any) (*Res[schema_test_mapped], error) {
+		return nil, nil
+	}).Throws(409, &openapi_test_input{}).Attach(mappedApp, "/mapped")
+	mappedSchema := mappedApp.Schema()
+	if data := mappedSchema.Procedures[0].Errors[0].Data; data.Kind != TypeObject || data.Name != "OpenapiTestInput" {
+		t.Fatalf(DefaultColors.Red+"Expected the pointer error data to be an OpenapiTestInput object, got %+v", data)
+	}
+	mappedType := reflect.TypeOf(schema_test_mapped{})
+	jsonSchemaBuilder := newJSONSchemaBuilder(mappedApp, "#/$defs/")
+	jsonSchema := jsonSchemaBuilder.object(jsonSchemaBuilder.models.structDecl(mappedType))
+	tsTypes := newInlineTSTypeRegistry(mappedApp)
+	models := newTypeModels(mappedApp)
+	expected := []struct {
+		kind     typeKind
+		jsonType string
+		tsType   string
+	}{
+		{kindTime, "string", "string"},
+		{kindString, "string", "string"},
+		{kindAny, "", "unknown"},
+		{kindString, "string", "string"},
+		{kindInt64, "string", "string"},
+		{kindAny, "", "unknown"},
+		{kindList, "array", "Array<number>"},
+	}
+	for i, want := range expected {
+		field := wireFields(mappedType)[i]
+		kind, jsonType, tsType := models.model(field.field.Type).kind, jsonSchema.Properties[field.name].Type, goTypeToTSType(tsTypes, field.field.Type)
+		if kind != want.kind || jsonType != want.jsonType || tsType != want.tsType {
+			t.Fatalf(DefaultColors.Red+"Expected %s to be %+v, got %v %q %q", field.name, want, kind, jsonType, tsType)
+		}
+	}
+
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not encode the schema : %s", err.Error())
+	}
+	var decoded Schema
+	if err := json.Unmarshal(encoded, &decoded); err != nil || len(decoded.Procedures) != 2 || decoded.Procedures[1].Input.Name != "TsgenTestMember" {
+		t.Fatalf(DefaultColors.Red+"Expected the schema to survive json, got %v %+v", err, decoded)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED SCHEMA" + DefaultColors.Reset)
+}
+
+func TestAddGenerator(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING ADD GENERATOR" + DefaultColors.Reset)
+	dir := t.TempDir()
+	app := New(&Config{
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+	})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users")
+
+	outputPath := filepath.Join(dir, "paths.txt")
+	app.AddGenerator(outputPath, GeneratorFunc(func(schema *Schema) ([]byte, error) {
+		builder := strings.Builder{}
+		for _, procedure := range schema.Procedures {
+			builder.WriteString(fmt.Sprintf("%s %s\n", procedure.Method, procedure.Path))
+		}
+		return []byte(builder.String()), nil
+	}))
+	if err := app.WriteClients(); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not write the clients : %s", err.Error())
+	}
+	written, err := os.ReadFile(outputPath)
+	if err != nil || string(written) != "query /users\n" {
+		t.Fatalf(DefaultColors.Red+"Expected the output of the generator, got %q %v", written, err)
+	}
+
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[any], error) {
+		return nil, nil
+	}).Attach(app, "/users/create")
+	if err := app.CheckClients(); err == nil || !strings.Contains(err.Error(), "paths.txt") {
+		t.Fatalf(DefaultColors.Red+"Expected the output of the generator to be out of date, got %v", err)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED ADD GENERATOR" + DefaultColors.Reset)
+}
@@ -4,14 +4,24 @@ import (
 	"net/http"
 )
 
-func attachProcedureToMux(mux *http.ServeMux, slug string, proc *ProcedureInfo, mws []Handler) {
+func attachProcedureToMux(mux *http.ServeMux, slug string, proc *ProcedureInfo, mws []Handler, app *App) {
 
 	mux.HandleFunc(slug, func(w http.ResponseWriter, r *http.Request) {
-		ctx := createCtx(w, r)
+		ctx := createCtx(w, r, app)
 		var allHandlersArray []Handler
 		allHandlersArray = append(allHandlersArray, mws...)
-		if !methodsMatch(r.Method, proc.method) {
+		if !methodsMatch(r.Method, proc) {
 			allHandlersArray = append(allHandlersArray, func(Ctx *Ctx) error {
+				// answers the preflight requests that browsers send before calling a mutation from another origin
+				if r.Method == http.MethodOptions && app.config.CORS_Origin != "" {
+					ctx.httpW.Header().Set("Access-Control-Allow-Methods", proc.httpMethod()+", OPTIONS")
+					if requestHeaders := r.Header.Get("Access-Control-Request-Headers"); requestHeaders != "" {
+						ctx.httpW.Header().Set("Access-Control-Allow-Headers", requestHeaders)
+					}
+					ctx.status(http.StatusNoContent)
+					return nil
+				}
+				ctx.httpW.Header().Set("Allow", proc.httpMethod())
 				return &Error{
 					Code:    405,
 					Message: "Method not allowed",
@@ -75,7 +75,7 @@ func TestStaticOutput(t *testing.T) {
 	app := New()
 	app.Static("/assets", "")
 	builder := strings.Builder{}
-	nodeToTS(&builder, app.startRoute, true, "")
+	nodeToTS(&builder, nil, app.startRoute, true, "")
 	if builder.String() != "{[`assets`]:{}}" {
 		t.Fail()
 	}
@@ -4,93 +4,151 @@ import (
 	"fmt"
 	"log"
 	"reflect"
-	"regexp"
 	"strings"
 )
 
-func goToTsObj(someStruct reflect.Type, dynamicSlugNames ...string) string {
-	stringBuilder := strings.Builder{}
-
-	if someStruct == nil {
+// writes the fields of a struct as an inline typescript object, exactly as they are put on the wire by encoding/json and marshalJSON (see wireFields).
+// Nested named structs are referenced by their name when a type registry is given and written inline otherwise
+func goToTsObj(types *tsTypeRegistry, someStruct reflect.Type) string {
+	someStruct, ok := asTSStruct(someStruct)
+	if !ok {
 		return "any"
 	}
-	if someStruct.Kind() == reflect.Ptr {
-		someStruct = someStruct.Elem()
-	}
-	if someStruct.Kind() == reflect.Interface {
-		return "any"
-	} else if someStruct.Kind() != reflect.Struct {
-		log.Panicf(" i though this was supposed to be a struct, it is %s", someStruct.Kind())
+	if types == nil {
+		types = newInlineTSTypeRegistry(nil)
 	}
+	return types.object(types.models.structDecl(someStruct))
+}
 
+// writes the fields of a struct declaration as an inline typescript object
+func (types *tsTypeRegistry) object(decl *typeDecl) string {
+	stringBuilder := strings.Builder{}
 	stringBuilder.WriteString("{")
-
-	for i := 0; i < someStruct.NumField(); i++ {
-		field := someStruct.Field(i)
-		fieldName := field.Name
-		fieldType := field.Type
-
-		paramName := field.Tag.Get("paramName")
-
-		if paramName != "" {
-			regex := regexp.MustCompile("[^a-zA-Z]+")
-			fieldName = regex.ReplaceAllString(paramName, "")
-		}
-		_, hasRequired := field.Tag.Lookup("required")
-		validateTag := field.Tag.Get("validate")
-		hasValidateRequired := strings.Contains(validateTag, "required")
-
-		// If the name is from a dynamic slug then just put Slug at the end
-		//Because dynamic slugs params are always required I put this else if here
-		if len(dynamicSlugNames) > 0 && sliceStrContains(dynamicSlugNames, fieldName) {
-			fieldName += "Slug"
-		} else if !hasRequired && !hasValidateRequired {
+	for _, field := range decl.fields {
+		fieldName := tsPropertyName(field.name)
+		// encoding/json always sends the fields that are not omitempty, the required tags only matter to the validation of queries and inputs
+		if !field.required {
 			fieldName += "?"
 		}
 
 		// Append TypeScript field definition to the StringBuilder
-		stringBuilder.WriteString(fmt.Sprintf(" %s: %s", fieldName, goTypeToTSType(fieldType)))
+		writeFieldDoc(&stringBuilder, field.doc)
+		stringBuilder.WriteString(fmt.Sprintf(" %s: %s", fieldName, types.fieldType(field)))
 
 		stringBuilder.WriteString(",")
+	}
+	stringBuilder.WriteString("}")
+	return stringBuilder.String()
+}
+
+// writes the query parameters as an inline typescript object, nil when the procedure only takes dynamic slugs.
+// The fields that fill a dynamic slug end in Slug and are required, the slugs that no field fills are strings
+func (types *tsTypeRegistry) queryObject(query *typeModel, slugs []string) string {
+	stringBuilder := strings.Builder{}
+	stringBuilder.WriteString("{")
+	if query != nil {
+		for _, field := range query.decl.fields {
+			fieldName := field.name
+			optional := ""
+			if field.slug {
+				fieldName += "Slug"
+			} else if !field.required {
+				optional = "?"
+			}
+
+			// Append TypeScript field definition to the StringBuilder
+			writeFieldDoc(&stringBuilder, field.doc)
+			stringBuilder.WriteString(fmt.Sprintf(" %s%s: %s", tsPropertyName(fieldName), optional, types.fieldType(field)))
 
+			stringBuilder.WriteString(",")
+		}
+	}
+	// dynamic slugs that have no matching field still need to be passed in order to build the address
+	for _, slugName := range slugs {
+		stringBuilder.WriteString(fmt.Sprintf(" %s: string,", tsPropertyName(slugName+"Slug")))
 	}
 	stringBuilder.WriteString("}")
 	return stringBuilder.String()
 }
 
-func goTypeToTSType(t reflect.Type) string {
-	if t == nil {
-		return "any"
+// returns the typescript type of a field, the union of the values of its enum tag if it has one
+func (types *tsTypeRegistry) fieldType(field fieldModel) string {
+	if enumType, ok := types.enumTagType(field); ok {
+		return enumType
 	}
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+	return types.tsType(field.model)
+}
+
+// dereferences the type and reports if it can be written as a typescript object. Interfaces can't, they are written as any
+func asTSStruct(someStruct reflect.Type) (reflect.Type, bool) {
+	if someStruct == nil {
+		return nil, false
 	}
-	// Check if the type is a named type and if its Kind is one of the basic types
-	if t.Name() != "" && t.Kind() != reflect.Struct && t.Kind() != reflect.Interface {
-		switch t.Kind() {
-		case reflect.String:
-			return "string"
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
-			return "number"
-		case reflect.Bool:
-			return "boolean"
-		}
+	if someStruct.Kind() == reflect.Ptr {
+		someStruct = someStruct.Elem()
+	}
+	if someStruct.Kind() == reflect.Interface {
+		return nil, false
+	} else if someStruct.Kind() != reflect.Struct {
+		log.Panicf(" i though this was supposed to be a struct, it is %s", someStruct.Kind())
 	}
+	return someStruct, true
+}
 
-	switch t.Kind() {
+// a field is required if it has a required tag or a required validation rule
+func isFieldRequired(field reflect.StructField) bool {
+	_, hasRequired := field.Tag.Lookup("required")
+	validateTag := field.Tag.Get("validate")
+	hasValidateRequired := strings.Contains(validateTag, "required")
+	return hasRequired || hasValidateRequired
+}
 
-	case reflect.Slice, reflect.Array:
-		elemType := goTypeToTSType(t.Elem())
-		return fmt.Sprintf("Array<%s>", elemType)
-	case reflect.Map:
-		keyType := goTypeToTSType(t.Key())
-		valueType := goTypeToTSType(t.Elem())
-		return fmt.Sprintf("Record<%s, %s>", keyType, valueType)
-	case reflect.Struct:
-		return goToTsObj(t)
-	case reflect.Interface:
+func goTypeToTSType(types *tsTypeRegistry, t reflect.Type) string {
+	if t == nil {
 		return "any"
+	}
+	if types == nil {
+		types = newInlineTSTypeRegistry(nil)
+	}
+	return types.tsType(types.models.model(t))
+}
+
+// returns the typescript type of a model
+func (types *tsTypeRegistry) tsType(model *typeModel) string {
+	if model == nil {
+		return "any"
+	}
+	if model.cycle != nil {
+		return types.tsType(model.cycle)
+	}
+	if tsType, ok := types.mappedType(model); ok {
+		return tsType
+	}
+
+	switch model.kind {
+	case kindOptional:
+		return types.tsType(model.elem)
+	case kindString:
+		return "string"
+	case kindInt, kindInt64, kindFloat:
+		return "number"
+	case kindBool:
+		return "boolean"
+	case kindList:
+		return types.composite(model.goType, func() string {
+			return fmt.Sprintf("Array<%s>", types.tsType(model.elem))
+		})
+	case kindMap:
+		return types.composite(model.goType, func() string {
+			return fmt.Sprintf("Record<%s, %s>", types.tsType(model.key), types.tsType(model.elem))
+		})
+	case kindObject:
+		if model.decl.goType.Name() != "" && !types.inline {
+			return types.reference(model.decl)
+		}
+		return types.composite(model.decl.goType, func() string {
+			return types.object(model.decl)
+		})
 	default:
 		return "any"
 	}
@@ -0,0 +1,96 @@
+package bluerpc
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Emitter is handed to every subscription handler. Each call to Send pushes a new event to the client over the open event stream
+type Emitter[T any] struct {
+	ctx      *Ctx
+	flusher  http.Flusher
+	validate func(event T) error
+	mutex    sync.Mutex
+}
+
+// Sends an event to the subscribed client. The event is validated like any other procedure output before being sent.
+// Returns an error if the client has already disconnected
+func (e *Emitter[T]) Send(event T) error {
+	if err := e.validate(event); err != nil {
+		return err
+	}
+	data, err := e.ctx.marshalJSON(event)
+	if err != nil {
+		return err
+	}
+	return e.write("", data)
+}
+
+// Done returns a channel that is closed once the client disconnects. Select on it inside of your subscription loops in order to stop sending events
+func (e *Emitter[T]) Done() <-chan struct{} {
+	return e.ctx.httpR.Context().Done()
+}
+
+func (e *Emitter[T]) sendError(err error) error {
+	body := Map{"message": err.Error()}
+	if bluerpcErr, ok := err.(*Error); ok {
+		body = bluerpcErr.body()
+	}
+	data, marshalErr := e.ctx.marshalJSON(body)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	return e.write("error", data)
+}
+
+// writes a single server sent event and flushes it to the client right away
+func (e *Emitter[T]) write(event string, data []byte) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.ctx.httpR.Context().Err(); err != nil {
+		return err
+	}
+	if event != "" {
+		if _, err := fmt.Fprintf(e.ctx.httpW, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(e.ctx.httpW, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	return nil
+}
+
+// opens the event stream and hands the emitter to the subscription handler.
+// Once the stream is open the status code can no longer change, so any error returned by the handler is sent as an "error" event instead
+func serveSubscription[query any, input any, output any](c *Ctx, proc *Procedure[query, input, output], queryInstance query, path string) error {
+	flusher, ok := c.httpW.(http.Flusher)
+	if !ok {
+		return &Error{
+			Code:    http.StatusInternalServerError,
+			Message: "streaming is not supported by this server",
+		}
+	}
+
+	c.Set("Content-Type", TextEventStream)
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.status(http.StatusOK)
+	flusher.Flush()
+
+	emitter := &Emitter[output]{
+		ctx:     c,
+		flusher: flusher,
+		validate: func(event output) error {
+			return validateOutput(proc, &Res[output]{Body: event}, path, proc.method)
+		},
+	}
+
+	if err := proc.subscriptionHandler(c, queryInstance, emitter); err != nil {
+		emitter.sendError(err)
+	}
+	return nil
+}
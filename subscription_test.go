@@ -0,0 +1,99 @@
+package bluerpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type subscription_test_output struct {
+	Count int `paramName:"count"`
+}
+
+func TestSubscription(t *testing.T) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	fmt.Println(DefaultColors.Green + "TESTING SUBSCRIPTION EVENTS" + DefaultColors.Reset)
+	app := New(&Config{
+		ValidatorFn:         validate.Struct,
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+	})
+
+	proc := NewSubscription(app, func(ctx *Ctx, query test_query, emitter *Emitter[subscription_test_output]) error {
+		for i := 0; i < 3; i++ {
+			if err := emitter.Send(subscription_test_output{Count: i}); err != nil {
+				return err
+			}
+		}
+		return &Error{Code: 400, Message: "done"}
+	})
+	proc.Attach(app, "/events")
+
+	req, err := http.NewRequest("GET", "http://localhost:8080/events?query=dwa", nil)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not create a new request : %s", err.Error())
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not do the request : %s", err.Error())
+	}
+
+	if !strings.Contains(res.Header.Get("Content-Type"), TextEventStream) {
+		t.Fatalf(DefaultColors.Red+"Subscription responded with the content type %s", res.Header.Get("Content-Type"))
+	}
+
+	var events []subscription_test_output
+	var errorEvents int
+	scanner := bufio.NewScanner(res.Body)
+	isErrorEvent := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "event: error" {
+			isErrorEvent = true
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		if isErrorEvent {
+			errorEvents++
+			isErrorEvent = false
+			continue
+		}
+		var event subscription_test_output
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf(DefaultColors.Red+"Failed to unmarshal event: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 3 || events[2].Count != 2 {
+		t.Fatalf(DefaultColors.Red+"Expected 3 events, got %v", events)
+	}
+	if errorEvents != 1 {
+		t.Fatalf(DefaultColors.Red+"Expected the returned error to be sent as an error event, got %d error events", errorEvents)
+	}
+
+	fmt.Println(DefaultColors.Green + "PASSED SUBSCRIPTION EVENTS" + DefaultColors.Reset)
+}
+
+func TestSubscriptionOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING SUBSCRIPTION OUTPUT" + DefaultColors.Reset)
+	app := New()
+	proc := NewSubscription(app, func(ctx *Ctx, query any, emitter *Emitter[subscription_test_output]) error {
+		return nil
+	})
+	proc.Attach(app, "/events")
+	builder := strings.Builder{}
+	nodeToTS(&builder, nil, app.startRoute, true, "")
+	if !strings.Contains(builder.String(), "subscribe: (headers?: HeadersInit,):RpcSubscription<{ count: number,}>=>{return rpcSubscribe(`/events`,undefined,headers)}") {
+		t.Fatalf(DefaultColors.Red+"Unexpected subscription output %s", builder.String())
+	}
+	fmt.Println(DefaultColors.Green + "PASSED SUBSCRIPTION OUTPUT" + DefaultColors.Reset)
+}
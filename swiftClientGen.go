@@ -0,0 +1,552 @@
+package bluerpc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the swift keywords that names are escaped from with backticks
+var swiftKeywords = map[string]bool{"associatedtype": true, "class": true, "deinit": true, "enum": true, "extension": true, "fileprivate": true, "func": true, "import": true,
+	"init": true, "inout": true, "internal": true, "let": true, "open": true, "operator": true, "private": true, "protocol": true, "public": true, "rethrows": true,
+	"static": true, "struct": true, "subscript": true, "typealias": true, "var": true, "break": true, "case": true, "continue": true, "default": true, "defer": true,
+	"do": true, "else": true, "fallthrough": true, "for": true, "guard": true, "if": true, "in": true, "repeat": true, "return": true, "switch": true, "where": true,
+	"while": true, "as": true, "catch": true, "false": true, "is": true, "nil": true, "self": true, "Self": true, "super": true, "throw": true, "throws": true,
+	"true": true, "try": true, "Any": true, "Type": true, "Protocol": true}
+
+// swiftClientGenerator writes the swift client of an app from the models of its types. Every struct becomes a Codable struct,
+// every registered enum a Codable enum and every router a struct with an async method per procedure, the same tree as the go client
+type swiftClientGenerator struct {
+	app    *App
+	models *typeModels
+}
+
+// GenerateSwiftClient returns the source of a swift client for every procedure of the app. It only needs Foundation (iOS 15+ or macOS 12+).
+// The client mirrors the router tree : the query attached at /users/{id} is called with try await client.users.byId(query)
+func (a *App) GenerateSwiftClient() ([]byte, error) {
+	g := &swiftClientGenerator{app: a, models: newTypeModels(a, "RpcClient", "RpcResponse", "RpcError", "RpcInt64", "JSONValue", "RpcCoding")}
+
+	routers := &strings.Builder{}
+	reserveClientRouterNames(a.startRoute, "Client", g.models.takenNames)
+	g.writeRouter(routers, a.startRoute, "Client", "")
+
+	source := &strings.Builder{}
+	source.WriteString(generatedHeader("//"))
+	source.WriteString(swiftRuntime)
+	if a.config.Int64Mode.sendsString(reflect.Int64) {
+		source.WriteString(swiftInt64AsString)
+	} else {
+		source.WriteString("\npublic typealias RpcInt64 = Int64\n")
+	}
+	for _, decl := range g.models.decls {
+		if decl.goType.Kind() == reflect.Struct {
+			g.writeStruct(source, decl)
+		} else {
+			g.writeEnum(source, decl)
+		}
+	}
+	source.WriteString(routers.String())
+	return []byte(source.String()), nil
+}
+
+func (g *swiftClientGenerator) writeRouter(stringBuilder *strings.Builder, router *Router, typeName string, currentPath string) {
+	members := routerMembers(router)
+
+	location := currentPath
+	if location == "" {
+		location = "/"
+	}
+	stringBuilder.WriteString(fmt.Sprintf("\n/// %s calls the procedures under %s\n", typeName, location))
+	stringBuilder.WriteString(fmt.Sprintf("public struct %s {\n", typeName))
+	stringBuilder.WriteString("    public let rpc: RpcClient\n\n")
+	if currentPath == "" {
+		baseURL := ""
+		if g.app.config.ServerURL != "" {
+			baseURL = " = " + strconv.Quote(g.app.config.ServerURL)
+		}
+		stringBuilder.WriteString(fmt.Sprintf("    public init(baseURL: String%s, session: URLSession = .shared, headers: [String: String] = [:]) {\n", baseURL))
+		stringBuilder.WriteString("        self.rpc = RpcClient(baseURL: baseURL, session: session, headers: headers)\n    }\n\n")
+	}
+	stringBuilder.WriteString("    public init(rpc: RpcClient) {\n        self.rpc = rpc\n    }\n")
+	for _, member := range members {
+		if member.router != nil {
+			routerType := clientRouterTypeName(typeName, member)
+			stringBuilder.WriteString(fmt.Sprintf("\n    public var %s: %s { %s(rpc: rpc) }\n", swiftName(lowerCamelName(member.name)), routerType, routerType))
+		}
+	}
+	for _, member := range members {
+		if member.proc != nil {
+			g.writeProcedure(stringBuilder, lowerCamelName(member.name), g.models.procedure(member.proc, currentPath+member.slug))
+		}
+	}
+	stringBuilder.WriteString("}\n")
+	for _, member := range members {
+		if member.router != nil {
+			g.writeRouter(stringBuilder, member.router, clientRouterTypeName(typeName, member), currentPath+member.slug)
+		}
+	}
+}
+
+// writes the method that calls a procedure. Queries and inputs that are any are left out of the parameters,
+// and the dynamic slugs of the path that have no matching query field become string parameters
+func (g *swiftClientGenerator) writeProcedure(stringBuilder *strings.Builder, methodName string, proc procedureModel) {
+	var params, args []string
+	if proc.query != nil {
+		params = append(params, "_ query: "+g.typeExpr(proc.query))
+		args = append(args, "query: query")
+	}
+	if proc.input != nil {
+		label := "_ input"
+		if proc.query != nil {
+			label = "input"
+		}
+		params = append(params, label+": "+g.typeExpr(proc.input))
+		args = append(args, "input: input")
+	}
+	var slugArgs []string
+	for _, slugName := range proc.slugs {
+		param := lowerCamelName(goParamName(slugName)) + "Slug"
+		params = append(params, param+": String")
+		slugArgs = append(slugArgs, fmt.Sprintf("%q: %s", slugName, param))
+	}
+	if len(slugArgs) > 0 {
+		args = append(args, "slugs: ["+strings.Join(slugArgs, ", ")+"]")
+	}
+	params = append(params, "headers: [String: String] = [:]")
+	args = append(args, "headers: headers")
+
+	outputType := g.typeExpr(proc.output)
+	stringBuilder.WriteString("\n")
+	for _, line := range clientDocLines(proc.method, proc.path, proc.docs) {
+		stringBuilder.WriteString(strings.TrimRight("    /// "+line, " ") + "\n")
+	}
+	for _, procErr := range proc.errors {
+		if procErr.data != nil {
+			stringBuilder.WriteString(fmt.Sprintf("    /// - Throws: RpcError, its data is a %s when its status is %d\n", g.typeExpr(procErr.data), procErr.code))
+		}
+	}
+	if proc.docs.deprecated {
+		stringBuilder.WriteString(fmt.Sprintf("    @available(*, deprecated, message: %s)\n", strconv.Quote(strings.TrimSpace(proc.path+" is deprecated "+proc.docs.deprecatedMessage()))))
+	}
+	switch proc.method {
+	case SUBSCRIPTION:
+		stringBuilder.WriteString(fmt.Sprintf("    public func %s(%s) -> AsyncThrowingStream<%s, Error> {\n", swiftName(methodName), strings.Join(params, ", "), outputType))
+		stringBuilder.WriteString(fmt.Sprintf("        rpc.subscribe(%q, %s)\n    }\n", proc.path, strings.Join(args, ", ")))
+	default:
+		stringBuilder.WriteString(fmt.Sprintf("    public func %s(%s) async throws -> RpcResponse<%s> {\n", swiftName(methodName), strings.Join(params, ", "), outputType))
+		stringBuilder.WriteString(fmt.Sprintf("        try await rpc.call(%q, %q, %s)\n    }\n", proc.httpMethod, proc.path, strings.Join(args, ", ")))
+	}
+}
+
+// returns the swift type of a model
+func (g *swiftClientGenerator) typeExpr(model *typeModel) string {
+	if model == nil {
+		return "JSONValue"
+	}
+	switch model.kind {
+	case kindString:
+		return "String"
+	case kindBool:
+		return "Bool"
+	case kindInt:
+		return "Int"
+	case kindInt64:
+		return "RpcInt64"
+	case kindFloat:
+		return "Double"
+	case kindTime:
+		return "Date"
+	case kindOptional:
+		return g.typeExpr(model.elem) + "?"
+	case kindList:
+		return "[" + g.typeExpr(model.elem) + "]"
+	case kindMap:
+		return "[String: " + g.typeExpr(model.elem) + "]"
+	case kindObject:
+		return model.decl.name
+	case kindEnum:
+		if model.decl.baseKind == kindBool {
+			return "Bool"
+		}
+		return model.decl.name
+	default:
+		return "JSONValue"
+	}
+}
+
+// writes a Codable struct with a public memberwise init. Types that can contain themselves are written as final classes, swift structs can not
+func (g *swiftClientGenerator) writeStruct(source *strings.Builder, decl *typeDecl) {
+	keyword := "struct"
+	if decl.isRecursive() {
+		keyword = "final class"
+	}
+	source.WriteString(fmt.Sprintf("\npublic %s %s: Codable {\n", keyword, decl.name))
+
+	names := make([]string, len(decl.fields))
+	types := make([]string, len(decl.fields))
+	taken := map[string]bool{}
+	for i, field := range decl.fields {
+		name := lowerCamelName(field.name)
+		for j := 2; taken[name]; j++ {
+			name = lowerCamelName(field.name) + strconv.Itoa(j)
+		}
+		taken[name] = true
+		names[i] = name
+
+		types[i] = g.typeExpr(field.model)
+		if !field.required && !strings.HasSuffix(types[i], "?") {
+			types[i] += "?"
+		}
+		if field.doc != "" {
+			for _, line := range strings.Split(field.doc, "\n") {
+				source.WriteString(strings.TrimRight("    /// "+line, " ") + "\n")
+			}
+		}
+		source.WriteString(fmt.Sprintf("    public var %s: %s\n", swiftName(name), types[i]))
+	}
+
+	if len(decl.fields) > 0 {
+		source.WriteString("\n    enum CodingKeys: String, CodingKey {\n")
+		for i, field := range decl.fields {
+			source.WriteString(fmt.Sprintf("        case %s = %s\n", swiftName(names[i]), strconv.Quote(field.name)))
+		}
+		source.WriteString("    }\n")
+	}
+
+	params := make([]string, len(decl.fields))
+	for i := range decl.fields {
+		params[i] = names[i] + ": " + types[i]
+		if strings.HasSuffix(types[i], "?") {
+			params[i] += " = nil"
+		}
+	}
+	source.WriteString(fmt.Sprintf("\n    public init(%s) {\n", strings.Join(params, ", ")))
+	for _, name := range names {
+		source.WriteString(fmt.Sprintf("        self.%s = %s\n", name, swiftName(name)))
+	}
+	source.WriteString("    }\n}\n")
+}
+
+func (g *swiftClientGenerator) writeEnum(source *strings.Builder, decl *typeDecl) {
+	rawType := map[typeKind]string{kindString: "String", kindInt: "Int", kindFloat: "Double"}[decl.baseKind]
+	if rawType == "" {
+		return
+	}
+	source.WriteString(fmt.Sprintf("\npublic enum %s: %s, Codable, CaseIterable {\n", decl.name, rawType))
+	taken := map[string]bool{}
+	for _, value := range decl.values {
+		literal := fmt.Sprint(value)
+		if decl.baseKind == kindString {
+			literal = strconv.Quote(literal)
+		}
+		name := lowerCamelName(fmt.Sprint(value))
+		if name == "" || (name[0] >= '0' && name[0] <= '9') {
+			name = "value" + toPascalCase(fmt.Sprint(value))
+		}
+		for i := 2; taken[name]; i++ {
+			name = lowerCamelName(fmt.Sprint(value)) + strconv.Itoa(i)
+		}
+		taken[name] = true
+		source.WriteString(fmt.Sprintf("    case %s = %s\n", swiftName(name), literal))
+	}
+	source.WriteString("}\n")
+}
+
+// escapes the swift keywords
+func swiftName(name string) string {
+	if swiftKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// turns a go or json name into a lower camel case name : ById becomes byId, created_at becomes createdAt and ID becomes id
+func lowerCamelName(name string) string {
+	name = toPascalCase(name)
+	upper := 0
+	for upper < len(name) && name[upper] >= 'A' && name[upper] <= 'Z' {
+		upper++
+	}
+	switch {
+	case upper == len(name):
+		return strings.ToLower(name)
+	case upper > 1:
+		// the last capital of an acronym starts the next word (URLPath becomes urlPath)
+		return strings.ToLower(name[:upper-1]) + name[upper-1:]
+	default:
+		return strings.ToLower(name[:upper]) + name[upper:]
+	}
+}
+
+const swiftInt64AsString = `
+/// An int64 or uint64 value, sent as a json string so that it keeps its precision
+public struct RpcInt64: Codable, Hashable, ExpressibleByIntegerLiteral, CustomStringConvertible {
+    public var value: Int64
+
+    public init(_ value: Int64) {
+        self.value = value
+    }
+
+    public init(integerLiteral value: Int64) {
+        self.value = value
+    }
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if let text = try? container.decode(String.self), let value = Int64(text) {
+            self.value = value
+        } else {
+            self.value = try container.decode(Int64.self)
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        try container.encode(String(value))
+    }
+
+    public var description: String { String(value) }
+}
+`
+
+// the part of the swift client that does not depend on the procedures : the responses, the errors and the calls
+const swiftRuntime = `import Foundation
+
+public struct RpcResponse<Body> {
+    public let body: Body
+    public let status: Int
+    public let headers: [AnyHashable: Any]
+}
+
+/// An error response of the server. Decode the data of the errors that the procedure declares with data(as:)
+public struct RpcError: Error, CustomStringConvertible {
+    public let status: Int
+    public let message: String
+    public let rawData: Data?
+
+    public func data<T: Decodable>(as type: T.Type) throws -> T? {
+        guard let rawData = rawData else { return nil }
+        return try RpcCoding.decoder.decode(type, from: rawData)
+    }
+
+    public var description: String { "\(status) \(message)" }
+}
+
+/// Any json value, the type of the values that are not typed on the server
+public enum JSONValue: Codable, Equatable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let value = try? container.decode(Bool.self) {
+            self = .bool(value)
+        } else if let value = try? container.decode(Double.self) {
+            self = .number(value)
+        } else if let value = try? container.decode(String.self) {
+            self = .string(value)
+        } else if let value = try? container.decode([JSONValue].self) {
+            self = .array(value)
+        } else {
+            self = .object(try container.decode([String: JSONValue].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .null: try container.encodeNil()
+        case .bool(let value): try container.encode(value)
+        case .number(let value): try container.encode(value)
+        case .string(let value): try container.encode(value)
+        case .array(let value): try container.encode(value)
+        case .object(let value): try container.encode(value)
+        }
+    }
+}
+
+public enum RpcCoding {
+    /// decodes the RFC 3339 dates that go sends, with or without fractional seconds
+    public static let decoder: JSONDecoder = {
+        let decoder = JSONDecoder()
+        decoder.dateDecodingStrategy = .custom { decoder in
+            let text = try decoder.singleValueContainer().decode(String.self)
+            let formatter = ISO8601DateFormatter()
+            formatter.formatOptions = [.withInternetDateTime, .withFractionalSeconds]
+            if let date = formatter.date(from: text) { return date }
+            formatter.formatOptions = [.withInternetDateTime]
+            if let date = formatter.date(from: text) { return date }
+            throw DecodingError.dataCorrupted(.init(codingPath: decoder.codingPath, debugDescription: "invalid date \(text)"))
+        }
+        return decoder
+    }()
+
+    public static let encoder: JSONEncoder = {
+        let encoder = JSONEncoder()
+        encoder.dateEncodingStrategy = .iso8601
+        return encoder
+    }()
+}
+
+/// Sends the calls of the client. Set the headers of every call (an Authorization header for example) on it
+public final class RpcClient {
+    public let baseURL: String
+    public let session: URLSession
+    public var headers: [String: String]
+
+    public init(baseURL: String, session: URLSession = .shared, headers: [String: String] = [:]) {
+        self.baseURL = baseURL.hasSuffix("/") ? String(baseURL.dropLast()) : baseURL
+        self.session = session
+        self.headers = headers
+    }
+
+    public func call<Output: Decodable>(_ method: String, _ path: String, query: (any Encodable)? = nil, input: (any Encodable)? = nil, slugs: [String: String] = [:], headers: [String: String] = [:]) async throws -> RpcResponse<Output> {
+        var request = try self.request(method, path, query: query, slugs: slugs, headers: headers)
+        if let input = input {
+            request.httpBody = try RpcCoding.encoder.encode(input)
+            request.setValue("application/json", forHTTPHeaderField: "Content-Type")
+        }
+        let (data, response) = try await session.data(for: request)
+        let httpResponse = response as? HTTPURLResponse
+        let status = httpResponse?.statusCode ?? 0
+        if status >= 400 {
+            throw RpcClient.error(status: status, data: data)
+        }
+        return RpcResponse(body: try RpcClient.decode(Output.self, data), status: status, headers: httpResponse?.allHeaderFields ?? [:])
+    }
+
+    public func subscribe<Output: Decodable>(_ path: String, query: (any Encodable)? = nil, slugs: [String: String] = [:], headers: [String: String] = [:]) -> AsyncThrowingStream<Output, Error> {
+        AsyncThrowingStream { continuation in
+            let task = Task {
+                do {
+                    var request = try self.request("GET", path, query: query, slugs: slugs, headers: headers)
+                    request.setValue("text/event-stream", forHTTPHeaderField: "Accept")
+                    let (bytes, response) = try await self.session.bytes(for: request)
+                    let status = (response as? HTTPURLResponse)?.statusCode ?? 0
+                    if status >= 400 {
+                        var data = Data()
+                        for try await byte in bytes { data.append(byte) }
+                        throw RpcClient.error(status: status, data: data)
+                    }
+                    // every event is sent on a single data line, the lines of the stream skip the empty lines between the events
+                    var name = "message"
+                    for try await line in bytes.lines {
+                        if line.hasPrefix("event:") {
+                            name = line.dropFirst("event:".count).trimmingCharacters(in: .whitespaces)
+                        } else if line.hasPrefix("data:") {
+                            var payload = String(line.dropFirst("data:".count))
+                            if payload.hasPrefix(" ") { payload.removeFirst() }
+                            if name == "error" {
+                                throw RpcClient.error(status: 500, data: Data(payload.utf8))
+                            }
+                            continuation.yield(try RpcClient.decode(Output.self, Data(payload.utf8)))
+                            name = "message"
+                        }
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    // the fields of the query fill the dynamic slugs that share their name, every other field becomes a query parameter
+    func request(_ method: String, _ path: String, query: (any Encodable)?, slugs: [String: String], headers: [String: String]) throws -> URLRequest {
+        var values = slugs
+        var items: [URLQueryItem] = []
+        let segments = path.split(separator: "/", omittingEmptySubsequences: false).map(String.init)
+        let slugNames = segments.filter { $0.hasPrefix("{") && $0.hasSuffix("}") }.map { String($0.dropFirst().dropLast()).replacingOccurrences(of: "...", with: "") }
+        if let query = query, let object = try JSONSerialization.jsonObject(with: RpcCoding.encoder.encode(query)) as? [String: Any] {
+            for key in object.keys.sorted() {
+                guard let value = RpcClient.queryValue(object[key]!) else { continue }
+                if slugNames.contains(key) {
+                    values[key] = value
+                } else {
+                    items.append(URLQueryItem(name: key, value: value))
+                }
+            }
+        }
+        var allowed = CharacterSet.urlPathAllowed
+        allowed.remove("/")
+        let filled = try segments.map { segment -> String in
+            guard segment.hasPrefix("{") && segment.hasSuffix("}") else { return segment }
+            let name = String(segment.dropFirst().dropLast())
+            if name == "$" { return "" }
+            let slugName = name.replacingOccurrences(of: "...", with: "")
+            guard let value = values[slugName] else {
+                throw RpcError(status: 0, message: "no value was given for the \(slugName) slug of \(path)", rawData: nil)
+            }
+            // wildcards that span multiple segments keep their slashes
+            return value.split(separator: "/", omittingEmptySubsequences: false).map { String($0).addingPercentEncoding(withAllowedCharacters: allowed) ?? String($0) }.joined(separator: name.hasSuffix("...") ? "/" : "%2F")
+        }
+        var components = URLComponents(string: baseURL + filled.joined(separator: "/"))!
+        if !items.isEmpty {
+            components.queryItems = items
+        }
+        var request = URLRequest(url: components.url!)
+        request.httpMethod = method
+        for (key, value) in self.headers.merging(headers, uniquingKeysWith: { $1 }) {
+            request.setValue(value, forHTTPHeaderField: key)
+        }
+        return request
+    }
+
+    static func queryValue(_ value: Any) -> String? {
+        switch value {
+        case is NSNull:
+            return nil
+        case let number as NSNumber:
+            // json booleans are NSNumbers as well
+            if String(cString: number.objCType) == "c" { return number.boolValue ? "true" : "false" }
+            return number.stringValue
+        case let text as String:
+            return text
+        case let list as [Any]:
+            return list.compactMap(queryValue).joined(separator: ",")
+        default:
+            return nil
+        }
+    }
+
+    static func decode<Output: Decodable>(_ type: Output.Type, _ data: Data) throws -> Output {
+        if data.isEmpty, let null = JSONValue.null as? Output {
+            return null
+        }
+        do {
+            return try RpcCoding.decoder.decode(type, from: data)
+        } catch {
+            // bodies that are not json are plain text
+            if let text = String(data: data, encoding: .utf8) as? Output {
+                return text
+            }
+            throw error
+        }
+    }
+
+    static func error(status: Int, data: Data) -> RpcError {
+        var message = String(data: data, encoding: .utf8)?.trimmingCharacters(in: .whitespacesAndNewlines) ?? ""
+        var rawData: Data?
+        if let object = try? JSONSerialization.jsonObject(with: data) as? [String: Any] {
+            if let text = object["message"] as? String, !text.isEmpty {
+                message = text
+            }
+            if let value = object["data"], !(value is NSNull) {
+                rawData = try? JSONSerialization.data(withJSONObject: value, options: [.fragmentsAllowed])
+            }
+        }
+        if message.isEmpty {
+            message = HTTPURLResponse.localizedString(forStatusCode: status)
+        }
+        return RpcError(status: status, message: message, rawData: rawData)
+    }
+}
+`
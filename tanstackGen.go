@@ -0,0 +1,106 @@
+package bluerpc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// writes rpcQueryKey, createRpcQuery and the rpcQuery object of rpcAPI (see Config.GenerateTanStackQuery). rpcQuery mirrors rpcAPI : every query gets a queryOptions factory for useQuery,
+// every mutation gets a mutationOptions factory for useMutation and every procedure and router gets its query key for invalidation. Failed calls throw an RpcError.
+// createRpcQuery gives the same helpers for a client made with createClient.
+// The factories only return plain objects, so they work with every TanStack Query adapter (react, vue, solid...) without importing it
+func writeTanStackQuery(stringBuilder *strings.Builder, app *App) {
+	if !app.config.GenerateTanStackQuery {
+		return
+	}
+	stringBuilder.WriteString("\nexport type RpcQueryKey = readonly unknown[];\n" +
+		"// the segments of the path come first so that invalidating the key of a router invalidates every procedure under it\n" +
+		"export function rpcQueryKey(path: string, query?: unknown): RpcQueryKey {\n" +
+		"  const segments = path.split('/').filter(segment => segment !== '');\n" +
+		"  return query === undefined ? segments : [...segments, query];\n" +
+		"}\n")
+	rpcQuery := strings.Builder{}
+	nodeToTanStackQuery(&rpcQuery, app.startRoute, "", nil)
+	stringBuilder.WriteString("// the TanStack Query helpers of a client, their calls go through that client\n" +
+		"export function createRpcQuery(api: RpcAPI) {\n  return " + formatTS(rpcQuery.String(), "  ") + " as const;\n}\n" +
+		"export const rpcQuery = createRpcQuery(rpcAPI);\n")
+}
+
+// writes the rpcQuery object of a router. apiKeys are the keys of the matching rpcAPI object
+func nodeToTanStackQuery(stringBuilder *strings.Builder, router *Router, currentPath string, apiKeys []string) {
+	stringBuilder.WriteString("{")
+	stringBuilder.WriteString(fmt.Sprintf("$key: () => rpcQueryKey(%q),", currentPath))
+
+	for _, slug := range getSortedKeys(router.procedures) {
+		proc := router.procedures[slug]
+		if proc.method != QUERY && proc.method != MUTATION {
+			continue
+		}
+		fullPath := currentPath + slug
+		keys := tsProcedureKeys(slug)
+
+		for _, key := range keys {
+			stringBuilder.WriteString(fmt.Sprintf("[`%s`]:{", key))
+		}
+		fn := string(proc.method)
+		if proc.protected {
+			fn = "_" + fn
+		}
+		procedure := newRPCAPIProcedure(append(append(append([]string{}, apiKeys...), keys...), fn))
+
+		hasQuery := !isInterpretedAsEmpty(proc.querySchema) || len(findDynamicSlugs(fullPath)) > 0
+		switch proc.method {
+		case QUERY:
+			writeTanStackQueryOptions(stringBuilder, procedure, fullPath, hasQuery)
+		case MUTATION:
+			writeTanStackMutationOptions(stringBuilder, procedure, fullPath, hasQuery || !isInterpretedAsEmpty(proc.inputSchema))
+		}
+
+		stringBuilder.WriteString(strings.Repeat("}", len(keys)))
+		stringBuilder.WriteString(",")
+	}
+
+	for _, path := range getSortedKeys(router.routes) {
+		key := tsRouterKey(path)
+		stringBuilder.WriteString(fmt.Sprintf("[`%s`]:", key))
+		nodeToTanStackQuery(stringBuilder, router.routes[path], currentPath+path, append(append([]string{}, apiKeys...), key))
+		stringBuilder.WriteString(",")
+	}
+	stringBuilder.WriteString("}")
+}
+
+// the expressions of a procedure of the api given to createRpcQuery, as a value and as a type
+type rpcAPIProcedure struct {
+	value    string
+	typeExpr string
+}
+
+func newRPCAPIProcedure(keys []string) rpcAPIProcedure {
+	procedure := rpcAPIProcedure{value: "api", typeExpr: "RpcAPI"}
+	for _, key := range keys {
+		procedure.value += fmt.Sprintf("[%q]", key)
+		procedure.typeExpr += fmt.Sprintf("[%q]", key)
+	}
+	return procedure
+}
+
+// the query is part of the key so that every set of query parameters is cached on its own. The key can be given a partial query in order to invalidate several of them
+func writeTanStackQueryOptions(stringBuilder *strings.Builder, procedure rpcAPIProcedure, fullPath string, hasQuery bool) {
+	if !hasQuery {
+		stringBuilder.WriteString(fmt.Sprintf("queryKey: () => rpcQueryKey(%q),", fullPath))
+		stringBuilder.WriteString(fmt.Sprintf("queryOptions: (headers?: HeadersInit) => ({queryKey: rpcQueryKey(%q), queryFn: () => %s(headers).then(rpcBody)}),", fullPath, procedure.value))
+		return
+	}
+	queryType := fmt.Sprintf("Parameters<%s>[0]", procedure.typeExpr)
+	stringBuilder.WriteString(fmt.Sprintf("queryKey: (query?: Partial<%s>) => rpcQueryKey(%q, query),", queryType, fullPath))
+	stringBuilder.WriteString(fmt.Sprintf("queryOptions: (query: %s, headers?: HeadersInit) => ({queryKey: rpcQueryKey(%q, query), queryFn: () => %s(query, headers).then(rpcBody)}),", queryType, fullPath, procedure.value))
+}
+
+func writeTanStackMutationOptions(stringBuilder *strings.Builder, procedure rpcAPIProcedure, fullPath string, isParams bool) {
+	stringBuilder.WriteString(fmt.Sprintf("mutationKey: () => rpcQueryKey(%q),", fullPath))
+	if !isParams {
+		stringBuilder.WriteString(fmt.Sprintf("mutationOptions: (headers?: HeadersInit) => ({mutationKey: rpcQueryKey(%q), mutationFn: () => %s(headers).then(rpcBody)}),", fullPath, procedure.value))
+		return
+	}
+	stringBuilder.WriteString(fmt.Sprintf("mutationOptions: (headers?: HeadersInit) => ({mutationKey: rpcQueryKey(%q), mutationFn: (parameters: Parameters<%s>[0]) => %s(parameters, headers).then(rpcBody)}),", fullPath, procedure.typeExpr, procedure.value))
+}
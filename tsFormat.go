@@ -0,0 +1,271 @@
+package bluerpc
+
+import (
+	"strings"
+)
+
+// a bracket that the formatter is inside of. broken braces get one member per line,
+// breakable parentheses let the brace that directly follows them be broken as well (z.object({...}) or ({...}))
+type tsScope struct {
+	open      byte
+	broken    bool
+	breakable bool
+}
+
+type tsFormatter struct {
+	source    string
+	indent    string
+	out       []byte
+	scopes    []tsScope
+	newLine   bool // the next token starts a new line
+	lineStart bool // the next token starts a line of the source that is not inside of any bracket
+	space     bool // the next token is preceded by a space
+}
+
+// formats the typescript that the generators write on a single line (the api object, the declarations, the schemas...).
+// Object literals, type literals and function bodies are broken into one member per line and indented with two spaces, on top of the given indent.
+// Braces nested in parentheses, brackets or type arguments stay inline unless they directly follow a parenthesis. Strings, template literals and comments are copied as they are
+func formatTS(source string, indent string) string {
+	f := &tsFormatter{source: source, indent: indent}
+	for i := 0; i < len(source); {
+		i = f.next(i)
+	}
+	return string(f.out)
+}
+
+// formats the token that starts at i and returns the index of the next one
+func (f *tsFormatter) next(i int) int {
+	c := f.source[i]
+	switch {
+	case c == '"' || c == '\'':
+		end := skipTSString(f.source, i)
+		f.emit(f.source[i:end])
+		return end
+	case c == '`':
+		end := skipTSTemplate(f.source, i)
+		f.emit(f.source[i:end])
+		return end
+	case strings.HasPrefix(f.source[i:], "/*"):
+		end := strings.Index(f.source[i+2:], "*/")
+		if end == -1 {
+			end = len(f.source)
+		} else {
+			end += i + 4
+		}
+		wasNewLine := f.newLine
+		f.emitComment(f.source[i:end])
+		// a comment written on its own line documents the member that follows it
+		f.newLine = wasNewLine
+		return end
+	case strings.HasPrefix(f.source[i:], "//"):
+		end := strings.IndexByte(f.source[i:], '\n')
+		if end == -1 {
+			end = len(f.source)
+		} else {
+			end += i
+		}
+		f.emit(f.source[i:end])
+		if len(f.scopes) > 0 {
+			f.newLine = true
+		}
+		return end
+	case c == '\n':
+		if len(f.scopes) == 0 {
+			f.out = append(f.out, '\n')
+			f.newLine, f.space, f.lineStart = false, false, true
+		}
+		return i + 1
+	case c == ' ' || c == '\t' || c == '\r':
+		if !f.newLine && !f.space && !f.lineStart {
+			f.out = append(f.out, c)
+		}
+		return i + 1
+	case c == '{':
+		return f.openBrace(i)
+	case c == '}':
+		f.closeBrace()
+		return i + 1
+	case c == '(' || c == '[':
+		top, hasTop := f.top()
+		breakable := c == '(' && (!hasTop || top.broken || top.breakable)
+		f.emit(string(c))
+		f.scopes = append(f.scopes, tsScope{open: c, breakable: breakable})
+		return i + 1
+	case c == ')' || c == ']':
+		f.emit(string(c))
+		f.pop()
+		return i + 1
+	case c == '<':
+		// only type arguments (Array<string>) are brackets, comparisons are written with spaces around them
+		isTypeArgument := len(f.out) > 0 && isTSIdentifierByte(f.out[len(f.out)-1])
+		f.emit("<")
+		if isTypeArgument {
+			f.scopes = append(f.scopes, tsScope{open: '<'})
+		}
+		return i + 1
+	case c == '>':
+		isArrow := len(f.out) > 0 && f.out[len(f.out)-1] == '='
+		f.emit(">")
+		if top, ok := f.top(); ok && !isArrow && top.open == '<' {
+			f.pop()
+		}
+		return i + 1
+	case c == ',':
+		f.emit(",")
+		if top, ok := f.top(); ok && top.broken {
+			f.newLine = true
+		}
+		return i + 1
+	case c == ':':
+		f.emit(":")
+		if top, ok := f.top(); ok && top.broken {
+			f.space = true
+		}
+		return i + 1
+	default:
+		f.emit(string(c))
+		return i + 1
+	}
+}
+
+func (f *tsFormatter) openBrace(i int) int {
+	top, hasTop := f.top()
+	lastByte := byte(0)
+	if len(f.out) > 0 {
+		lastByte = f.out[len(f.out)-1]
+	}
+	broken := !hasTop || top.broken || (top.open == '(' && top.breakable && lastByte == '(')
+	if !broken {
+		f.emit("{")
+		f.scopes = append(f.scopes, tsScope{open: '{'})
+		return i + 1
+	}
+
+	// empty objects stay on one line
+	rest := strings.TrimLeft(f.source[i+1:], " \t")
+	if strings.HasPrefix(rest, "}") {
+		f.emit("{}")
+		return len(f.source) - len(rest) + 1
+	}
+
+	if !f.newLine && !f.lineStart && lastByte != 0 && lastByte != ' ' && lastByte != '(' && lastByte != '\n' {
+		f.space = true
+	}
+	f.emit("{")
+	f.scopes = append(f.scopes, tsScope{open: '{', broken: true})
+	f.newLine = true
+	return i + 1
+}
+
+func (f *tsFormatter) closeBrace() {
+	top, ok := f.top()
+	if !ok || !top.broken {
+		f.emit("}")
+		f.pop()
+		return
+	}
+	f.pop()
+	f.out = []byte(strings.TrimRight(string(f.out), " \t"))
+	f.out = append(f.out, '\n')
+	f.out = append(f.out, f.indentation()...)
+	f.out = append(f.out, '}')
+	f.newLine, f.space = false, false
+}
+
+// writes a token, preceded by the pending line break or space
+func (f *tsFormatter) emit(token string) {
+	switch {
+	case f.newLine:
+		f.out = []byte(strings.TrimRight(string(f.out), " \t"))
+		f.out = append(f.out, '\n')
+		f.out = append(f.out, f.indentation()...)
+	case f.lineStart:
+		f.out = append(f.out, f.indent...)
+	case f.space && !strings.HasPrefix(token, " "):
+		f.out = append(f.out, ' ')
+	}
+	f.newLine, f.space, f.lineStart = false, false, false
+	f.out = append(f.out, token...)
+}
+
+// writes a block comment, the lines after the first one are indented to the depth of the comment (the " * " of a JSDoc stays aligned)
+func (f *tsFormatter) emitComment(comment string) {
+	lines := strings.Split(comment, "\n")
+	f.emit(lines[0])
+	for _, line := range lines[1:] {
+		f.out = append(f.out, '\n')
+		f.out = append(f.out, f.indentation()+" "+strings.TrimLeft(line, " \t")...)
+	}
+}
+
+// the indentation of the current depth : the base indent followed by two spaces for every broken brace
+func (f *tsFormatter) indentation() string {
+	depth := 0
+	for _, scope := range f.scopes {
+		if scope.broken {
+			depth++
+		}
+	}
+	return f.indent + strings.Repeat("  ", depth)
+}
+
+func (f *tsFormatter) top() (tsScope, bool) {
+	if len(f.scopes) == 0 {
+		return tsScope{}, false
+	}
+	return f.scopes[len(f.scopes)-1], true
+}
+
+func (f *tsFormatter) pop() {
+	if len(f.scopes) > 0 {
+		f.scopes = f.scopes[:len(f.scopes)-1]
+	}
+}
+
+func isTSIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// returns the index right after the string that starts at i
+func skipTSString(source string, i int) int {
+	quote := source[i]
+	for j := i + 1; j < len(source); j++ {
+		switch source[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return len(source)
+}
+
+// returns the index right after the template literal that starts at i, skipping over the expressions (${...}) inside of it
+func skipTSTemplate(source string, i int) int {
+	for j := i + 1; j < len(source); j++ {
+		switch {
+		case source[j] == '\\':
+			j++
+		case source[j] == '`':
+			return j + 1
+		case strings.HasPrefix(source[j:], "${"):
+			depth := 0
+			for j = j + 2; j < len(source); j++ {
+				switch source[j] {
+				case '"', '\'':
+					j = skipTSString(source, j) - 1
+				case '`':
+					j = skipTSTemplate(source, j) - 1
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+				if depth < 0 {
+					break
+				}
+			}
+		}
+	}
+	return len(source)
+}
@@ -0,0 +1,227 @@
+package bluerpc
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// a module of the split typescript client (see Config.SplitTSOutput)
+type tsModule struct {
+	// the name of the file without its extension, the other modules import it from ./name
+	name         string
+	source       string
+	declarations []tsDeclaration
+}
+
+// a top level declaration of a module
+type tsDeclaration struct {
+	name   string
+	isType bool
+	// public declarations were exported by the generator in the single file client as well, the index re-exports them.
+	// The other declarations are only exported so that the modules can import them from each other
+	public bool
+}
+
+var tsTopLevelDeclaration = regexp.MustCompile(`^(export )?(?:async )?(function|const|let|class|type|interface) ([A-Za-z_$][\w$]*)`)
+
+// the JS reserved words that a router can not be named after
+var tsReservedWords = map[string]bool{"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true, "if": true, "import": true, "in": true,
+	"instanceof": true, "new": true, "null": true, "return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true, "try": true, "typeof": true,
+	"var": true, "void": true, "while": true, "with": true, "yield": true, "let": true, "static": true, "await": true}
+
+// returns the modules of the split typescript client. The index is written at indexPath and the other modules next to it :
+// runtime holds the functions that every call goes through, types the named types and their schemas, then comes one module per top level router
+// (root for the procedures attached to the app) with the schemas of its procedures and finally the index, which re-exports them and combines the routers into rpcAPI and rpcSchemas
+func generateTsModules(app *App, indexPath string) []tsModule {
+	indexName := strings.TrimSuffix(filepath.Base(indexPath), filepath.Ext(indexPath))
+	taken := map[string]bool{"runtime": true, "types": true, indexName: true}
+
+	// the routers are generated first so that every type they use is known by the time the declarations are written
+	types := newTSTypeRegistry(app)
+	type routerAPI struct {
+		key, name, factory, api string
+		// the zod schemas of the procedures of the router, exported under schemasName
+		schemasName string
+		schemas     map[string]string
+	}
+	var routers []routerAPI
+	// every router records the schemas of its procedures on its own so that its module holds them
+	generateRouter := func(router *Router, path string, routerAPI routerAPI) routerAPI {
+		routerAPI.schemasName = routerAPI.name + "Schemas"
+		types.schemasName = routerAPI.schemasName
+		types.procedureSchemas = map[string]string{}
+		api := strings.Builder{}
+		nodeToTS(&api, types, router, true, path)
+		routerAPI.api = api.String()
+		routerAPI.schemas = types.procedureSchemas
+		return routerAPI
+	}
+	taken["rootSchemas"] = true
+	if hasTSProcedures(app.startRoute) {
+		routers = append(routers, generateRouter(&Router{procedures: app.startRoute.procedures}, "", routerAPI{name: "root", factory: "createRootAPI"}))
+	}
+	taken["root"] = true
+	for _, path := range getSortedKeys(app.startRoute.routes) {
+		key := tsRouterKey(path)
+		name := tsModuleName(key, taken)
+		taken[name+"Schemas"] = true
+		routers = append(routers, generateRouter(app.startRoute.routes[path], path, routerAPI{key: key, name: name, factory: "create" + strings.ToUpper(name[:1]) + name[1:] + "API"}))
+	}
+	// the schemas of the procedures are in the modules of their routers, types only holds the schemas of the named types
+	types.procedureSchemas = nil
+
+	runtime := strings.Builder{}
+	writeTsRuntime(&runtime, app)
+	modules := []tsModule{newTSModule("runtime", runtime.String(), nil)}
+
+	typesSource := strings.Builder{}
+	if app.config.GenerateZod {
+		typesSource.WriteString("import { z } from \"zod\";\n")
+	}
+	writeTsTypes(&typesSource, types)
+	modules = append(modules, newTSModule("types", typesSource.String(), modules))
+	shared := modules
+
+	rpcAPI := strings.Builder{}
+	rpcAPI.WriteString("{")
+	rpcSchemas := strings.Builder{}
+	for _, router := range routers {
+		source := strings.Builder{}
+		if app.config.GenerateZod {
+			writeProcedureSchemas(&source, router.schemasName, router.schemas)
+			rpcSchemas.WriteString("..." + router.schemasName + ",")
+		}
+		writeTsAPIFactory(&source, router.factory, router.api)
+		source.WriteString(fmt.Sprintf("export const %s = /* @__PURE__ */ %s({});\n", router.name, router.factory))
+		module := newTSModule(router.name, source.String(), shared)
+		for i := range module.declarations {
+			module.declarations[i].public = true
+		}
+		modules = append(modules, module)
+
+		if router.key == "" {
+			rpcAPI.WriteString(fmt.Sprintf("...%s(client),", router.factory))
+		} else {
+			rpcAPI.WriteString(fmt.Sprintf("[`%s`]:%s(client),", router.key, router.factory))
+		}
+	}
+	rpcAPI.WriteString("}")
+	index := strings.Builder{}
+	index.WriteString("function createRpcAPI(client: RpcClientOptions) {\n  return ")
+	index.WriteString(formatTS(rpcAPI.String(), "  "))
+	index.WriteString(" as const;\n}\n")
+	writeRpcAPI(&index, app)
+	if app.config.GenerateZod {
+		index.WriteString("export const rpcSchemas = " + formatTS("{"+rpcSchemas.String()+"}", "") + ";\n")
+	}
+
+	// the index keeps its own declarations to itself apart from the ones that the single file client exports as well
+	reExports := strings.Builder{}
+	for _, module := range modules {
+		writeTSReExports(&reExports, module)
+	}
+	modules = append(modules, tsModule{
+		name:   indexName,
+		source: generatedHeader("//") + reExports.String() + tsImports(index.String(), modules) + index.String(),
+	})
+	return modules
+}
+
+// creates a module from its body : every top level declaration is exported and the declarations of the given modules that the body uses are imported
+func newTSModule(name string, body string, imported []tsModule) tsModule {
+	module := tsModule{name: name}
+	// the imports of packages that the body starts with (zod) come before the imports of the other modules
+	packageImports := ""
+	for strings.HasPrefix(body, "import ") {
+		end := strings.IndexByte(body, '\n') + 1
+		packageImports += body[:end]
+		body = body[end:]
+	}
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		match := tsTopLevelDeclaration.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		module.declarations = append(module.declarations, tsDeclaration{
+			name:   match[3],
+			isType: match[2] == "type" || match[2] == "interface",
+			public: match[1] != "",
+		})
+		if match[1] == "" {
+			lines[i] = "export " + line
+		}
+	}
+	module.source = generatedHeader("//") + packageImports + tsImports(body, imported) + strings.Join(lines, "\n")
+	return module
+}
+
+// returns the imports of the declarations of the given modules that the body uses. Names inside of strings and paths are not uses
+func tsImports(body string, imported []tsModule) string {
+	builder := strings.Builder{}
+	for _, other := range imported {
+		var names []string
+		for _, declaration := range other.declarations {
+			if !regexp.MustCompile("(^|[^\\w$.'\"`/]|\\.\\.\\.)" + regexp.QuoteMeta(declaration.name) + "([^\\w$'\"`/]|$)").MatchString(body) {
+				continue
+			}
+			names = append(names, declaration.importName())
+		}
+		if len(names) > 0 {
+			builder.WriteString(fmt.Sprintf("import { %s } from \"./%s\";\n", strings.Join(names, ", "), other.name))
+		}
+	}
+	return builder.String()
+}
+
+// the name of the declaration in an import or an export list, types are imported with type so that they are erased
+func (declaration tsDeclaration) importName() string {
+	if declaration.isType {
+		return "type " + declaration.name
+	}
+	return declaration.name
+}
+
+// re-exports the public declarations of a module from the index
+func writeTSReExports(builder *strings.Builder, module tsModule) {
+	var names []string
+	for _, declaration := range module.declarations {
+		if !declaration.public {
+			continue
+		}
+		names = append(names, declaration.importName())
+	}
+	if len(names) > 0 {
+		builder.WriteString(fmt.Sprintf("export { %s } from \"./%s\";\n", strings.Join(names, ", "), module.name))
+	}
+}
+
+// returns the name of the module of a top level router, which is also the name of the api object it exports. It is a valid identifier that no other module uses
+func tsModuleName(key string, taken map[string]bool) string {
+	name := toPascalCase(key)
+	if name == "" {
+		name = "router"
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+	if name[0] >= '0' && name[0] <= '9' || tsReservedWords[name] {
+		name = "_" + name
+	}
+	for taken[name] {
+		name += "Router"
+	}
+	taken[name] = true
+	return name
+}
+
+// reports if procedures other than static ones are attached directly to the router
+func hasTSProcedures(router *Router) bool {
+	for _, proc := range router.procedures {
+		if proc.method != STATIC {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package bluerpc
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tsTypeRegistry collects every named struct used by the procedures so that the generated typescript declares each of them once and references it by name everywhere else
+type tsTypeRegistry struct {
+	names        map[reflect.Type]string
+	types        map[string]reflect.Type
+	declarations map[string]string
+
+	// the named types that are currently being expanded and the ones that turned out to refer back to themselves while being expanded
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]string
+
+	// an inline registry writes every struct in place instead of declaring it. It is used wherever there is no file to put declarations in (PrintInfo for example)
+	inline bool
+
+	// the models of the types, which carry the typescript types set with OverrideTSType and the values of the registered enums, and the way int64 values are sent (see Config.Int64Mode)
+	models    *typeModels
+	int64Mode Int64Mode
+
+	// where the bigints are inside every named struct, only used when int64 values are sent as bigints (see bigIntSpec)
+	bigIntNames map[reflect.Type]string
+	bigIntSpecs map[string]string
+
+	// the zod schemas of every named type and of every procedure, only generated when Config.GenerateZod is set.
+	// The procedures refer to their schemas through schemasName, rpcSchemas unless the client is split (see Config.SplitTSOutput)
+	zod              bool
+	zodDeclarations  map[string]string
+	procedureSchemas map[string]string
+	schemasName      string
+}
+
+// creates a registry that follows the type settings of the app. The app can be nil
+func newTSTypeRegistry(app *App) *tsTypeRegistry {
+	types := &tsTypeRegistry{
+		names:        map[reflect.Type]string{},
+		types:        map[string]reflect.Type{},
+		declarations: map[string]string{},
+		visiting:     map[reflect.Type]bool{},
+		recursive:    map[reflect.Type]string{},
+		bigIntNames:  map[reflect.Type]string{},
+		bigIntSpecs:  map[string]string{},
+
+		zodDeclarations:  map[string]string{},
+		procedureSchemas: map[string]string{},
+		schemasName:      "rpcSchemas",
+		models:           newTypeModels(app),
+	}
+	if app != nil {
+		types.int64Mode = app.config.Int64Mode
+		types.zod = app.config.GenerateZod
+	}
+	return types
+}
+
+func newInlineTSTypeRegistry(app *App) *tsTypeRegistry {
+	types := newTSTypeRegistry(app)
+	types.inline = true
+	return types
+}
+
+// returns the name under which the struct is declared, declaring it first if this is the first time that it is used
+func (types *tsTypeRegistry) reference(decl *typeDecl) string {
+	t := decl.goType
+	if name, ok := types.names[t]; ok {
+		return name
+	}
+	name := types.uniqueName(t)
+	types.names[t] = name
+	types.types[name] = t
+	types.declarations[name] = fmt.Sprintf("export interface %s %s", name, types.object(decl))
+	return name
+}
+
+// expands a composite type (a slice, an array, a map or a struct written inline).
+// If a named type refers back to itself while it is being expanded, that inner reference is written by name and the type is declared as a recursive type alias (inline registries only write the name)
+func (types *tsTypeRegistry) composite(t reflect.Type, expand func() string) string {
+	if t.Name() == "" {
+		return expand()
+	}
+	if name, ok := types.names[t]; ok {
+		return name
+	}
+	if types.visiting[t] {
+		name, ok := types.recursive[t]
+		if !ok {
+			name = types.uniqueName(t)
+			types.types[name] = t
+			types.recursive[t] = name
+		}
+		return name
+	}
+
+	types.visiting[t] = true
+	body := expand()
+	delete(types.visiting, t)
+
+	name, isRecursive := types.recursive[t]
+	if !isRecursive || types.inline {
+		return body
+	}
+	types.names[t] = name
+	types.declarations[name] = fmt.Sprintf("export type %s = %s", name, body)
+	return name
+}
+
+// writes every declaration, sorted by name
+func (types *tsTypeRegistry) writeDeclarations(builder *strings.Builder) {
+	for _, name := range getSortedKeys(types.declarations) {
+		builder.WriteString(formatTS(types.declarations[name], ""))
+		builder.WriteString("\n")
+	}
+}
+
+// returns the typescript name of the type. If another type (from another package) already took that name then the name gets qualified by the package name
+func (types *tsTypeRegistry) uniqueName(t reflect.Type) string {
+	name := tsTypeName(t)
+	if _, taken := types.types[name]; !taken {
+		return name
+	}
+	qualified := toPascalCase(path.Base(t.PkgPath())) + name
+	candidate := qualified
+	for i := 2; ; i++ {
+		if _, taken := types.types[candidate]; !taken {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", qualified, i)
+	}
+}
+
+var (
+	packageQualifierRegex = regexp.MustCompile(`(?:[\w\-]+[./])+`)
+	nonIdentifierRegex    = regexp.MustCompile(`[^A-Za-z0-9_$]+`)
+)
+
+// turns the name of a go type into a valid typescript identifier. Generic types keep the short names of their type arguments, Page[github.com/shop/models.User] becomes Page_User
+func tsTypeName(t reflect.Type) string {
+	name := packageQualifierRegex.ReplaceAllString(t.Name(), "")
+	name = strings.Trim(nonIdentifierRegex.ReplaceAllString(name, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// turns a snake, kebab or dotted name into PascalCase. users-api becomes UsersApi
+func toPascalCase(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	builder := strings.Builder{}
+	for _, part := range parts {
+		builder.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return builder.String()
+}
+
+// returns the property name as it should be written in a typescript object type, quoting it when it is not a valid identifier
+func tsPropertyName(name string) string {
+	if isTSIdentifier(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func isTSIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		isLetter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '$'
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !(i > 0 && isDigit) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,638 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tsgen_test_user struct {
+	Name string `paramName:"name" validate:"required"`
+}
+type tsgen_test_order struct {
+	Buyer  tsgen_test_user   `paramName:"buyer" validate:"required"`
+	Seller *tsgen_test_user  `paramName:"seller"`
+	Others []tsgen_test_user `paramName:"others"`
+}
+
+// generates the typescript declarations and the api object of an app
+func generateTestTS(app *App) (string, string) {
+	types := newTSTypeRegistry(app)
+	api := strings.Builder{}
+	nodeToTS(&api, types, app.startRoute, true, "")
+	declarations := strings.Builder{}
+	types.writeDeclarations(&declarations)
+	return declarations.String(), api.String()
+}
+
+func TestNamedTypesOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING NAMED TYPES OUTPUT" + DefaultColors.Reset)
+	app := New()
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_order], error) {
+		return nil, nil
+	}).Attach(app, "/order")
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[[]tsgen_test_order], error) {
+		return nil, nil
+	}).Attach(app, "/orders")
+
+	declarations, api := generateTestTS(app)
+
+	expectedDeclarations := "export interface tsgen_test_order {\n  buyer: tsgen_test_user,\n  seller: tsgen_test_user,\n  others: Array<tsgen_test_user>,\n}\n" +
+		"export interface tsgen_test_user {\n  name: string,\n}\n"
+	if declarations != expectedDeclarations {
+		t.Fatalf(DefaultColors.Red+"Unexpected declarations : %s", declarations)
+	}
+	if !strings.Contains(api, "Promise<RpcResponse<tsgen_test_order>>") || !strings.Contains(api, "parameters : {input:tsgen_test_user},") || !strings.Contains(api, "Promise<RpcResponse<Array<tsgen_test_order>>>") {
+		t.Fatalf(DefaultColors.Red+"The procedures do not reference the declared types : %s", api)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED NAMED TYPES OUTPUT" + DefaultColors.Reset)
+}
+
+func TestNamedTypesCollision(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING NAMED TYPES COLLISIONS" + DefaultColors.Reset)
+	app := New()
+
+	type Page[T any] struct {
+		Items []T `paramName:"items" validate:"required"`
+	}
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[Page[tsgen_test_user]], error) {
+		return nil, nil
+	}).Attach(app, "/users")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[Page[User]], error) {
+		return nil, nil
+	}).Attach(app, "/others")
+
+	first := func() any {
+		type Item struct{ First string }
+		return Item{}
+	}()
+	second := func() any {
+		type Item struct{ Second string }
+		return Item{}
+	}()
+	types := newTSTypeRegistry(app)
+	firstName := goTypeToTSType(types, getType(first))
+	secondName := goTypeToTSType(types, getType(second))
+	if firstName != "Item" || secondName != "BluerpcItem" {
+		t.Fatalf(DefaultColors.Red+"Colliding names were not qualified : %s %s", firstName, secondName)
+	}
+
+	declarations, _ := generateTestTS(app)
+	if !strings.Contains(declarations, "export interface Page_tsgen_test_user {\n  items: Array<tsgen_test_user>,\n}") || !strings.Contains(declarations, "export interface Page_User {") {
+		t.Fatalf(DefaultColors.Red+"Generic types were not named after their type arguments : %s", declarations)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED NAMED TYPES COLLISIONS" + DefaultColors.Reset)
+}
+
+type tsgen_test_node struct {
+	Name     string            `paramName:"name" validate:"required"`
+	Children []tsgen_test_node `paramName:"children"`
+	Parent   *tsgen_test_node  `paramName:"parent"`
+}
+type tsgen_test_tree map[string]tsgen_test_tree
+
+func TestRecursiveTypesOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING RECURSIVE TYPES OUTPUT" + DefaultColors.Reset)
+	app := New()
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_node], error) {
+		return nil, nil
+	}).Attach(app, "/node")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_tree], error) {
+		return nil, nil
+	}).Attach(app, "/tree")
+
+	declarations, api := generateTestTS(app)
+	expectedDeclarations := "export interface tsgen_test_node {\n  name: string,\n  children: Array<tsgen_test_node>,\n  parent: tsgen_test_node,\n}\n" +
+		"export type tsgen_test_tree = Record<string, tsgen_test_tree>\n"
+	if declarations != expectedDeclarations {
+		t.Fatalf(DefaultColors.Red+"Unexpected recursive declarations : %s", declarations)
+	}
+	if !strings.Contains(api, "Promise<RpcResponse<tsgen_test_tree>>") {
+		t.Fatalf(DefaultColors.Red+"The recursive alias is not referenced by name : %s", api)
+	}
+
+	inline := goTypeToTSType(nil, getType(new(tsgen_test_node)))
+	if inline != "{ name: string, children: Array<tsgen_test_node>, parent: tsgen_test_node,}" {
+		t.Fatalf(DefaultColors.Red+"Unexpected inline recursive type : %s", inline)
+	}
+	// printing the routes writes every type inline and must not overflow either
+	app.PrintRoutes()
+	fmt.Println(DefaultColors.Green + "PASSED RECURSIVE TYPES OUTPUT" + DefaultColors.Reset)
+}
+
+type tsgen_test_base struct {
+	Id      int    `json:"id" validate:"required"`
+	Created string `json:"created_at,omitempty"`
+}
+type tsgen_test_account struct {
+	tsgen_test_base
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"-"`
+	Balance  int64  `json:"balance,string" validate:"required"`
+	Nick     string `json:"nick-name,omitempty" validate:"required"`
+}
+
+func TestJSONTagsOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING JSON TAGS OUTPUT" + DefaultColors.Reset)
+
+	tsType := goToTsObj(nil, getType(tsgen_test_account{}))
+	expectedType := `{ id: number, created_at?: string, email: string, balance: string, "nick-name"?: string,}`
+	if tsType != expectedType {
+		t.Fatalf(DefaultColors.Red+"Unexpected typescript object : %s", tsType)
+	}
+
+	// the generated type must describe exactly what is put on the wire
+	c := &Ctx{}
+	encoded, err := c.marshalJSON(tsgen_test_account{
+		tsgen_test_base: tsgen_test_base{Id: 1},
+		Email:           "a@b.c",
+		Password:        "secret",
+		Balance:         10,
+	})
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not marshal : %s", err)
+	}
+	expectedJSON := `{"balance":"10","email":"a@b.c","id":1}`
+	if string(encoded) != expectedJSON {
+		t.Fatalf(DefaultColors.Red+"Unexpected json : %s", encoded)
+	}
+
+	decoded := tsgen_test_account{}
+	c.httpR = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(encoded)))
+	if err := c.decodeJSON(&decoded); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not decode : %s", err)
+	}
+	if decoded.Id != 1 || decoded.Email != "a@b.c" || decoded.Balance != 10 {
+		t.Fatalf(DefaultColors.Red+"Unexpected decoded value : %+v", decoded)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED JSON TAGS OUTPUT" + DefaultColors.Reset)
+}
+
+type tsgen_test_id [2]byte
+
+func (id tsgen_test_id) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%x", id[:])), nil
+}
+
+type tsgen_test_decimal struct{ cents int64 }
+
+func (d tsgen_test_decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%d.%02d", d.cents/100, d.cents%100))
+}
+
+type tsgen_test_mapped struct {
+	Created time.Time          `json:"created" validate:"required"`
+	Data    []byte             `json:"data" validate:"required"`
+	Raw     json.RawMessage    `json:"raw" validate:"required"`
+	Id      tsgen_test_id      `json:"id" validate:"required"`
+	Amount  int64              `json:"amount" validate:"required"`
+	Price   tsgen_test_decimal `json:"price" validate:"required"`
+}
+
+func TestTypeMappingsOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING TYPE MAPPINGS OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{Int64Mode: Int64AsString})
+	OverrideTSType[tsgen_test_decimal](app, "`${number}.${number}`")
+
+	tsType := goToTsObj(newInlineTSTypeRegistry(app), getType(tsgen_test_mapped{}))
+	expectedType := "{ created: string, data: string, raw: unknown, id: string, amount: string, price: `${number}.${number}`,}"
+	if tsType != expectedType {
+		t.Fatalf(DefaultColors.Red+"Unexpected typescript object : %s", tsType)
+	}
+
+	c := &Ctx{app: app}
+	encoded, err := c.marshalJSON(&tsgen_test_mapped{
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:    []byte("hi"),
+		Raw:     json.RawMessage(`{"a":1}`),
+		Id:      tsgen_test_id{1, 2},
+		Amount:  9007199254740993,
+		Price:   tsgen_test_decimal{cents: 1250},
+	})
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not marshal : %s", err)
+	}
+	expectedJSON := `{"amount":"9007199254740993","created":"2024-01-02T03:04:05Z","data":"aGk=","id":"0102","price":"12.50","raw":{"a":1}}`
+	if string(encoded) != expectedJSON {
+		t.Fatalf(DefaultColors.Red+"Unexpected json : %s", encoded)
+	}
+
+	decoded := tsgen_test_mapped{}
+	c.httpR = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":"9007199254740993","created":"2024-01-02T03:04:05Z","data":"aGk="}`))
+	if err := c.decodeJSON(&decoded); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not decode : %s", err)
+	}
+	if decoded.Amount != 9007199254740993 || string(decoded.Data) != "hi" || decoded.Created.Year() != 2024 {
+		t.Fatalf(DefaultColors.Red+"Unexpected decoded value : %+v", decoded)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED TYPE MAPPINGS OUTPUT" + DefaultColors.Reset)
+}
+
+func TestBigIntOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING BIGINT OUTPUT" + DefaultColors.Reset)
+	type tsgen_test_balance struct {
+		Amount  int64            `json:"amount" validate:"required"`
+		History []uint64         `json:"history" validate:"required"`
+		ByDay   map[string]int64 `json:"by_day" validate:"required"`
+		Label   string           `json:"label" validate:"required"`
+	}
+	app := New(&Config{Int64Mode: Int64AsBigInt})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[[]tsgen_test_balance], error) {
+		return nil, nil
+	}).Attach(app, "/balances")
+
+	types := newTSTypeRegistry(app)
+	api := strings.Builder{}
+	nodeToTS(&api, types, app.startRoute, true, "")
+	specs := strings.Builder{}
+	types.writeBigIntSpecs(&specs)
+
+	if !strings.Contains(api.String(), "{return reviveResponse(rpcCall(`/balances`,'GET',undefined,headers),[\"a\",\"tsgen_test_balance\"])}") {
+		t.Fatalf(DefaultColors.Red+"The response is not revived : %s", api.String())
+	}
+	if !strings.Contains(specs.String(), "const bigIntSpecs: Record<string, BigIntSpec> = {\n  \"tsgen_test_balance\": {\n    \"amount\": 1,\n    \"history\": [\"a\",1],\n    \"by_day\": [\"m\",1]\n  },\n};") {
+		t.Fatalf(DefaultColors.Red+"Unexpected bigint specs : %s", specs.String())
+	}
+
+	type tsgen_test_account_tree struct {
+		Total    int64                     `json:"total"`
+		Children []tsgen_test_account_tree `json:"children"`
+	}
+	if spec := types.bigIntSpec(types.models.model(getType(tsgen_test_tree{}))); spec != "" {
+		t.Fatalf(DefaultColors.Red+"A recursive type without bigints got a spec : %s", spec)
+	}
+	if spec := types.bigIntSpec(types.models.model(getType(tsgen_test_account_tree{}))); spec != `"tsgen_test_account_tree"` || types.bigIntSpecs["tsgen_test_account_tree"] != `{"total":1,"children":["a","tsgen_test_account_tree"]}` {
+		t.Fatalf(DefaultColors.Red+"Unexpected recursive bigint spec : %s %s", spec, types.bigIntSpecs["tsgen_test_account_tree"])
+	}
+	fmt.Println(DefaultColors.Green + "PASSED BIGINT OUTPUT" + DefaultColors.Reset)
+}
+
+type tsgen_test_status string
+
+const (
+	tsgen_test_active   tsgen_test_status = "active"
+	tsgen_test_disabled tsgen_test_status = "disabled"
+)
+
+type tsgen_test_member struct {
+	Status   tsgen_test_status `json:"status" validate:"required"`
+	Role     string            `json:"role" enum:"admin, member"`
+	Priority []int             `json:"priority" enum:"1,2,3"`
+}
+
+type tsgen_test_levels struct {
+	Level int `json:"level" enum:"low,high"`
+}
+
+type tsgen_test_numbers struct {
+	Ratio float32 `json:"ratio" enum:"0.1,0.2"`
+	Scale float64 `json:"scale" enum:"1.0,2.5"`
+	Step  int     `json:"step" enum:"+1,+2"`
+}
+
+func TestEnumsOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING ENUMS OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{ValidateEnums: true})
+	RegisterEnum(app, tsgen_test_active, tsgen_test_disabled)
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_member], error) {
+		return nil, nil
+	}).Attach(app, "/member")
+
+	declarations, _ := generateTestTS(app)
+	expectedDeclarations := "export interface tsgen_test_member {\n  status: tsgen_test_status,\n  role: \"admin\" | \"member\",\n  priority: Array<1 | 2 | 3>,\n}\n" +
+		"export type tsgen_test_status = \"active\" | \"disabled\"\n"
+	if declarations != expectedDeclarations {
+		t.Fatalf(DefaultColors.Red+"Unexpected declarations : %s", declarations)
+	}
+
+	c := &Ctx{app: app}
+	if err := c.validateEnums(&tsgen_test_member{Status: tsgen_test_active, Role: "admin", Priority: []int{1, 3}}); err != nil {
+		t.Fatalf(DefaultColors.Red+"A valid value was rejected : %s", err)
+	}
+	if err := c.validateEnums(&tsgen_test_member{}); err != nil {
+		t.Fatalf(DefaultColors.Red+"Zero values were rejected : %s", err)
+	}
+	for _, invalid := range []*tsgen_test_member{
+		{Status: "deleted"},
+		{Role: "owner"},
+		{Priority: []int{1, 4}},
+	} {
+		if err := c.validateEnums(invalid); err == nil {
+			t.Fatalf(DefaultColors.Red+"An invalid value was accepted : %+v", invalid)
+		}
+	}
+	if err := c.validateEnums(&tsgen_test_member{Role: "owner"}); err == nil || !strings.Contains(err.Error(), "for role,") {
+		t.Fatalf(DefaultColors.Red+"Expected the error to name the json field, got %v", err)
+	}
+
+	expectPanic := func(name string, fn func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf(DefaultColors.Red+"Expected %s to panic", name)
+			}
+		}()
+		fn()
+	}
+	expectPanic("an empty enum", func() { RegisterEnum[tsgen_test_status](app) })
+	expectPanic("a number enum tag with names", func() {
+		NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_levels], error) {
+			return nil, nil
+		}).Attach(app, "/levels")
+	})
+
+	numbers := &tsgen_test_numbers{Ratio: 0.1, Scale: 1, Step: 1}
+	if err := c.validateEnums(numbers); err != nil {
+		t.Fatalf(DefaultColors.Red+"A number was compared as text : %s", err)
+	}
+	if texts := enumTagTexts(reflect.TypeOf(numbers).Elem().Field(1)); strings.Join(texts, ",") != "1,2.5" {
+		t.Fatalf(DefaultColors.Red+"Unexpected enum values : %v", texts)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED ENUMS OUTPUT" + DefaultColors.Reset)
+}
+
+type tsgen_test_signup struct {
+	Email    string           `json:"email" validate:"required,email"`
+	Name     string           `json:"name" validate:"required,min=2,max=32"`
+	Age      int              `json:"age,omitempty" validate:"gte=18,lte=130"`
+	Plan     string           `json:"plan,omitempty" validate:"oneof=free pro"`
+	Code     string           `json:"code,omitempty" validate:"len=6"`
+	Tags     []string         `json:"tags,omitempty" validate:"max=5,dive,min=1"`
+	Referrer *tsgen_test_user `json:",omitempty"`
+}
+
+func TestZodOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING ZOD OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{GenerateZod: true})
+
+	type zod_test_query struct {
+		Page int `paramName:"page" validate:"min=1"`
+	}
+	NewMutation(app, func(ctx *Ctx, query zod_test_query, input tsgen_test_signup) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/teams/{team}/signup")
+
+	types := newTSTypeRegistry(app)
+	api := strings.Builder{}
+	nodeToTS(&api, types, app.startRoute, true, "")
+	schemas := strings.Builder{}
+	types.writeZodSchemas(&schemas)
+
+	expectedSchemas := []string{
+		"export const tsgen_test_signupSchema: z.ZodType<tsgen_test_signup> = z.lazy(() => z.object({\n" +
+			"  email: z.string().email(),\n" +
+			"  name: z.string().min(2).max(32),\n" +
+			"  age: z.number().int().gte(18).lte(130).optional(),\n" +
+			"  plan: z.union([z.literal(\"free\"), z.literal(\"pro\")]).optional(),\n" +
+			"  code: z.string().length(6).optional(),\n" +
+			"  tags: z.array(z.string()).max(5).optional(),\n" +
+			"  Referrer: tsgen_test_userSchema.optional(),\n" +
+			"}));",
+		"export const tsgen_test_userSchema: z.ZodType<tsgen_test_user> = z.lazy(() => z.object({\n  name: z.string(),\n}));",
+		"export const rpcSchemas = {\n" +
+			"  \"/teams/{team}/signup\": {\n" +
+			"    query: z.object({\n" +
+			"      page: z.number().int().min(1).optional(),\n" +
+			"      teamSlug: z.string(),\n" +
+			"    }),\n" +
+			"    input: tsgen_test_signupSchema,\n" +
+			"    output: tsgen_test_userSchema\n" +
+			"  },\n" +
+			"};",
+	}
+	for _, expected := range expectedSchemas {
+		if !strings.Contains(schemas.String(), expected) {
+			t.Fatalf(DefaultColors.Red+"Missing schema %s in : %s", expected, schemas.String())
+		}
+	}
+	if !strings.Contains(api.String(), "{return validateResponse(rpcCall(`/teams/${encodeURIComponent(String(parameters.query.teamSlug))}/signup`,'POST',parameters,headers),rpcSchemas[\"/teams/{team}/signup\"].output)}") {
+		t.Fatalf(DefaultColors.Red+"The response is not validated : %s", api.String())
+	}
+	fmt.Println(DefaultColors.Green + "PASSED ZOD OUTPUT" + DefaultColors.Reset)
+}
+
+func TestTanStackQueryOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING TANSTACK QUERY OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{GenerateTanStackQuery: true})
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_user) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Protected().Attach(app, "/users/create")
+
+	output := strings.Builder{}
+	writeTanStackQuery(&output, app)
+
+	expected := []string{
+		"export function rpcQueryKey(path: string, query?: unknown): RpcQueryKey {",
+		"export function createRpcQuery(api: RpcAPI) {\n  return {\n",
+		"    [`users`]: {\n      $key: () => rpcQueryKey(\"/users\"),\n",
+		"        mutationKey: () => rpcQueryKey(\"/users/create\"),\n" +
+			"        mutationOptions: (headers?: HeadersInit) => ({\n" +
+			"          mutationKey: rpcQueryKey(\"/users/create\"),\n" +
+			"          mutationFn: (parameters: Parameters<RpcAPI[\"users\"][\"create\"][\"_mutation\"]>[0]) => api[\"users\"][\"create\"][\"_mutation\"](parameters, headers).then(rpcBody)\n" +
+			"        }),\n",
+		"        queryKey: (query?: Partial<Parameters<RpcAPI[\"users\"][\"{id}\"][\"query\"]>[0]>) => rpcQueryKey(\"/users/{id}\", query),\n",
+		"        queryOptions: (query: Parameters<RpcAPI[\"users\"][\"{id}\"][\"query\"]>[0], headers?: HeadersInit) => ({\n" +
+			"          queryKey: rpcQueryKey(\"/users/{id}\", query),\n" +
+			"          queryFn: () => api[\"users\"][\"{id}\"][\"query\"](query, headers).then(rpcBody)\n" +
+			"        }),\n",
+		"export const rpcQuery = createRpcQuery(rpcAPI);\n",
+	}
+	for _, part := range expected {
+		if !strings.Contains(output.String(), part) {
+			t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, output.String())
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED TANSTACK QUERY OUTPUT" + DefaultColors.Reset)
+}
+
+func TestClientOptionsOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING CLIENT OPTIONS OUTPUT" + DefaultColors.Reset)
+	outputPath := filepath.Join(t.TempDir(), "output.ts")
+	app := New(&Config{OutputPath: outputPath, BatchPath: "/batch"})
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/users")
+
+	if err := generateTs(app); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the typescript : %s", err.Error())
+	}
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not read the typescript : %s", err.Error())
+	}
+
+	expected := []string{
+		"export type RpcClientOptions = {",
+		"const res = await rpcSend(client, { url, init: requestOptions });",
+		"const res = await rpcSend(client, { url: (client.baseUrl ?? host) + batchPath, init });",
+		"function createRpcAPI(client: RpcClientOptions) {",
+		"return clientCall<T>(client, apiRoute, method, params, headers);",
+		"rpcCall(`/users`,'GET',undefined,headers)",
+		"export const rpcAPI = createRpcAPI({});",
+		"export function createClient(options: RpcClientOptions = {}): RpcAPI {",
+	}
+	for _, part := range expected {
+		if !strings.Contains(string(output), part) {
+			t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, output)
+		}
+	}
+
+	// every client connects its own websocket, with its own url and headers
+	socketApp := New(&Config{WebSocketPath: "/ws"})
+	NewQuery(socketApp, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(socketApp, "/users")
+	socketOutput := generateTsSource(socketApp)
+	for _, part := range []string{
+		"const webSocketLink = webSocketLinks.get(client);",
+		"webSocketLink.call<T>(method, buildPath(apiRoute, params?.query), params?.input, await requestHeaders(client, headers));",
+		"webSocketLink.subscribe<T>(buildPath(apiRoute, params?.query), requestHeaders(client, headers));",
+		"export function connectWebSocket(url?: string, client?: object): WebSocketLink {",
+		"const base = options.baseUrl ?? (host || `${location.protocol}//${location.host}`);",
+		"export const rpcAPI = createRpcAPI(defaultClient);\nclientOptions.set(rpcAPI, defaultClient);",
+		"  const api = createRpcAPI(options);\n  clientOptions.set(api, options);",
+	} {
+		if !strings.Contains(socketOutput, part) {
+			t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, socketOutput)
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED CLIENT OPTIONS OUTPUT" + DefaultColors.Reset)
+}
+
+func TestTypedErrorsOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING TYPED ERRORS OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{Int64Mode: Int64AsBigInt})
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Throws(http.StatusNotFound, tsgen_test_mapped{}).Throws(http.StatusConflict, nil).Attach(app, "/users")
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/me")
+
+	_, api := generateTestTS(app)
+
+	expected := []string{
+		"query: async (headers?: HeadersInit,):Promise<RpcResponse<tsgen_test_user, { status: 404; message: string; data: tsgen_test_mapped } | { status: 409; message: string; data?: undefined } | RpcUnknownError>>=>",
+		"{return reviveResponse(rpcCall(`/users`,'GET',undefined,headers),undefined,{404:\"tsgen_test_mapped\",})}",
+		"query: async (headers?: HeadersInit,):Promise<RpcResponse<tsgen_test_user>>=>{return rpcCall(`/me`,'GET',undefined,headers)}",
+	}
+	for _, part := range expected {
+		if !strings.Contains(api, part) {
+			t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, api)
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED TYPED ERRORS OUTPUT" + DefaultColors.Reset)
+}
+
+func TestFormattedOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING FORMATTED OUTPUT" + DefaultColors.Reset)
+	compact := "{[`{id}`]:{query: async (query:{ idSlug: string,},headers?: HeadersInit,):Promise<RpcResponse<{ a: string }>>=>{return rpcCall(`/users/${encodeURIComponent(String(query.idSlug))}`,'GET',{query},headers)}},empty:{},text:\"{,}\",}"
+	expected := "{\n" +
+		"    [`{id}`]: {\n" +
+		"      query: async (query:{ idSlug: string,},headers?: HeadersInit,): Promise<RpcResponse<{ a: string }>>=> {\n" +
+		"        return rpcCall(`/users/${encodeURIComponent(String(query.idSlug))}`,'GET',{query},headers)\n" +
+		"      }\n" +
+		"    },\n" +
+		"    empty: {},\n" +
+		"    text: \"{,}\",\n" +
+		"  }"
+	if formatted := formatTS(compact, "  "); formatted != expected {
+		t.Fatalf(DefaultColors.Red+"Unexpected formatting : %s", formatted)
+	}
+
+	app := New(&Config{GenerateZod: true, GenerateTanStackQuery: true, Int64Mode: Int64AsBigInt})
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_mapped], error) {
+		return nil, nil
+	}).Attach(app, "/mapped/{id}")
+	NewMutation(app, func(ctx *Ctx, query any, input tsgen_test_signup) (*Res[tsgen_test_user], error) {
+		return nil, nil
+	}).Attach(app, "/signup")
+	first := generateTsSource(app)
+	if !strings.HasPrefix(first, "// Code generated by bluerpc. DO NOT EDIT.\n") {
+		t.Fatalf(DefaultColors.Red+"The output does not start with the generated header : %s", first)
+	}
+	for i := 0; i < 5; i++ {
+		if generateTsSource(app) != first {
+			t.Fatalf(DefaultColors.Red + "Generating the same app twice gave different outputs")
+		}
+	}
+	fmt.Println(DefaultColors.Green + "PASSED FORMATTED OUTPUT" + DefaultColors.Reset)
+}
+
+type tsgen_test_documented struct {
+	Name string `json:"name" doc:"the name shown to the other users"`
+}
+
+func TestProcedureDocsOutput(t *testing.T) {
+	fmt.Println(DefaultColors.Green + "TESTING PROCEDURE DOCS OUTPUT" + DefaultColors.Reset)
+	app := New(&Config{DisableGenerateTS: true, DisableInfoPrinting: true})
+
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_documented], error) {
+		return &Res[tsgen_test_documented]{Body: tsgen_test_documented{Name: "ada"}}, nil
+	}).Summary("Lists the users").Describe("Ends with */ on purpose").Tags("users", "admin").Deprecated("2024-01-02").Attach(app, "/users")
+
+	declarations, api := generateTestTS(app)
+	formatted := formatTS(api, "")
+	if part := "    /**\n     * Lists the users\n     *\n     * Ends with *\\/ on purpose\n     * @tags users, admin\n     * @deprecated since 2024-01-02\n     */\n    query: async"; !strings.Contains(formatted, part) {
+		t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, formatted)
+	}
+	if part := "  /** the name shown to the other users */\n  name: string,"; !strings.Contains(declarations, part) {
+		t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, declarations)
+	}
+
+	document, err := app.OpenAPI()
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the OpenAPI document : %s", err)
+	}
+	for _, part := range []string{`"summary": "Lists the users"`, `"deprecated": true`, `"description": "the name shown to the other users"`} {
+		if !strings.Contains(string(document), part) {
+			t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, document)
+		}
+	}
+
+	goClient, err := app.GenerateGoClient("users")
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not generate the go client : %s", err)
+	}
+	if part := "// Users calls the query at /users\n// Lists the users\n// Ends with */ on purpose\n//\n// Deprecated: since 2024-01-02\n"; !strings.Contains(string(goClient), part) {
+		t.Fatalf(DefaultColors.Red+"Missing %s in : %s", part, goClient)
+	}
+
+	recorder := httptest.NewRecorder()
+	ctx := createCtx(recorder, httptest.NewRequest(http.MethodGet, "/users", nil), app)
+	if err := app.startRoute.procedures["/users"].handler(ctx); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not call the procedure : %s", err)
+	}
+	if deprecation := recorder.Header().Get("Deprecation"); deprecation != "@1704153600" {
+		t.Fatalf(DefaultColors.Red+"Expected the Deprecation header to hold the date, got %q", deprecation)
+	}
+
+	// the header can only hold a date, a version is only shown in the clients
+	NewQuery(app, func(ctx *Ctx, query any) (*Res[tsgen_test_documented], error) {
+		return &Res[tsgen_test_documented]{Body: tsgen_test_documented{Name: "ada"}}, nil
+	}).Deprecated("v2").Attach(app, "/members")
+	recorder = httptest.NewRecorder()
+	ctx = createCtx(recorder, httptest.NewRequest(http.MethodGet, "/members", nil), app)
+	if err := app.startRoute.procedures["/members"].handler(ctx); err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not call the procedure : %s", err)
+	}
+	if deprecation, ok := recorder.Header()["Deprecation"]; ok {
+		t.Fatalf(DefaultColors.Red+"Expected no Deprecation header without a date, got %q", deprecation)
+	}
+	fmt.Println(DefaultColors.Green + "PASSED PROCEDURE DOCS OUTPUT" + DefaultColors.Reset)
+}
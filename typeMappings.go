@@ -0,0 +1,306 @@
+package bluerpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Int64Mode determines how int64 and uint64 values are sent over the wire and typed in the generated typescript
+type Int64Mode string
+
+const (
+	// int64 and uint64 values are sent as json numbers and typed as number. Values above 2^53 lose precision in javascript
+	Int64AsNumber Int64Mode = "number"
+	// int64 and uint64 values are sent as json strings and typed as string
+	Int64AsString Int64Mode = "string"
+	// int64 and uint64 values are sent as json strings and typed as bigint. The generated client turns them into bigints when it receives them
+	Int64AsBigInt Int64Mode = "bigint"
+)
+
+// reports if int64 and uint64 values of the given kind are sent as json strings
+func (m Int64Mode) sendsString(kind reflect.Kind) bool {
+	return (m == Int64AsString || m == Int64AsBigInt) && (kind == reflect.Int64 || kind == reflect.Uint64)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// the way encoding/json puts the values of a type on the wire when it does not follow the go structure of the type.
+// Every generator (typescript, zod, JSON Schema, the go client and the type models of the other clients) maps types through wireEncodingOf so that they agree
+type wireEncoding int
+
+const (
+	// the value is encoded out of its go structure : a struct field by field, a slice element by element...
+	encodedAsStructure wireEncoding = iota
+	// time.Time values, sent as RFC 3339 strings
+	encodedAsTime
+	// json.RawMessage and the types that implement json.Marshaler, any json value
+	encodedAsRawJSON
+	// json.Number values, sent as json numbers
+	encodedAsNumber
+	// the types that implement encoding.TextMarshaler, sent as strings
+	encodedAsText
+	// byte slices, sent as base64 strings
+	encodedAsBytes
+	// int64 and uint64 values, sent as strings or numbers depending on Int64Mode
+	encodedAsInt64
+)
+
+// returns the way the values of the type are put on the wire. Pointers and interfaces are encodedAsStructure, the callers look at what they hold
+func wireEncodingOf(t reflect.Type) wireEncoding {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return encodedAsTime
+	case reflect.TypeOf(json.RawMessage{}):
+		return encodedAsRawJSON
+	case reflect.TypeOf(json.Number("")):
+		return encodedAsNumber
+	}
+	if t.Kind() == reflect.Interface || t.Kind() == reflect.Ptr {
+		return encodedAsStructure
+	}
+	if implements(t, jsonMarshalerType) {
+		return encodedAsRawJSON
+	}
+	if implements(t, textMarshalerType) {
+		return encodedAsText
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return encodedAsBytes
+	}
+	if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+		return encodedAsInt64
+	}
+	return encodedAsStructure
+}
+
+// OverrideTSType sets the typescript type that is generated for every value of type T.
+// Use it for the types that have their own json encoding, for example OverrideTSType[uuid.UUID](app, "string") or OverrideTSType[decimal.Decimal](app, "string")
+func OverrideTSType[T any](app *App, tsType string) {
+	app.tsTypeOverrides[reflect.TypeOf((*T)(nil)).Elem()] = tsType
+}
+
+// returns the typescript type of the models that are not written out of their go structure: overrides, enums, builtins, custom marshalers, byte slices (sent as base64) and int64 values
+func (types *tsTypeRegistry) mappedType(model *typeModel) (string, bool) {
+	if model.override != "" {
+		return model.override, true
+	}
+	if model.kind == kindEnum {
+		return types.enumType(model.decl), true
+	}
+	switch model.encoding {
+	case encodedAsTime, encodedAsText, encodedAsBytes:
+		return "string", true
+	case encodedAsRawJSON:
+		return "unknown", true
+	case encodedAsNumber:
+		return "number", true
+	case encodedAsInt64:
+		switch types.int64Mode {
+		case Int64AsString:
+			return "string", true
+		case Int64AsBigInt:
+			return "bigint", true
+		}
+	}
+	return "", false
+}
+
+// reports if the type or a pointer to it implements the interface
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || (t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(iface))
+}
+
+// returns the json encoding of the values that encode themselves (time.Time, json.RawMessage, custom marshalers...) and of byte slices.
+// It reports false for every other value
+func marshalSelf(v reflect.Value) (json.RawMessage, bool, error) {
+	if !v.IsValid() || !v.CanInterface() || v.Kind() == reflect.Interface {
+		return nil, false, nil
+	}
+	t := v.Type()
+	switch {
+	case v.Kind() == reflect.Ptr && v.IsNil():
+		return nil, false, nil
+	case t.Implements(jsonMarshalerType), t.Implements(textMarshalerType):
+		encoded, err := json.Marshal(v.Interface())
+		return encoded, true, err
+	case v.CanAddr() && (implements(t, jsonMarshalerType) || implements(t, textMarshalerType)):
+		encoded, err := json.Marshal(v.Addr().Interface())
+		return encoded, true, err
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		encoded, err := json.Marshal(v.Interface())
+		return encoded, true, err
+	}
+	return nil, false, nil
+}
+
+// returns the typescript literal that tells the generated client where the bigints are inside a value of the given model, or "" if there are none.
+// Leaves are 1, arrays and maps are ["a", elem] and ["m", value], struct fields are objects and named types are referenced by the name of their entry in bigIntSpecs
+func (types *tsTypeRegistry) bigIntSpec(model *typeModel) string {
+	if types == nil || types.int64Mode != Int64AsBigInt || model == nil {
+		return ""
+	}
+	if model.cycle != nil {
+		return types.bigIntSpec(model.cycle)
+	}
+	if tsType, ok := types.mappedType(model); ok {
+		if tsType == "bigint" {
+			return "1"
+		}
+		return ""
+	}
+	if model.kind == kindOptional {
+		return types.bigIntSpec(model.elem)
+	}
+
+	if !types.hasBigInt(model, map[reflect.Type]bool{}) {
+		return ""
+	}
+	switch model.kind {
+	case kindList, kindMap, kindObject:
+		if model.goType.Name() != "" {
+			return types.namedBigIntSpec(model)
+		}
+	}
+	return types.bigIntExpression(model)
+}
+
+// reports if a value of the model can hold a bigint somewhere
+func (types *tsTypeRegistry) hasBigInt(model *typeModel, visiting map[reflect.Type]bool) bool {
+	if model.cycle != nil {
+		return types.hasBigInt(model.cycle, visiting)
+	}
+	if tsType, ok := types.mappedType(model); ok {
+		return tsType == "bigint"
+	}
+	if model.goType == nil || visiting[model.goType] {
+		return false
+	}
+	visiting[model.goType] = true
+	switch model.kind {
+	case kindOptional, kindList, kindMap:
+		return types.hasBigInt(model.elem, visiting)
+	case kindObject:
+		for _, field := range model.decl.fields {
+			if types.hasBigInt(field.model, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returns the reference to the entry of a named composite type in bigIntSpecs, adding the entry the first time
+func (types *tsTypeRegistry) namedBigIntSpec(model *typeModel) string {
+	t := model.goType
+	if name, ok := types.bigIntNames[t]; ok {
+		if name == "" {
+			return ""
+		}
+		return fmt.Sprintf("%q", name)
+	}
+	// the name is reserved before the type is expanded so that recursive types refer to their own entry
+	name := tsTypeName(t)
+	for i := 2; types.bigIntSpecs[name] != ""; i++ {
+		name = fmt.Sprintf("%s%d", tsTypeName(t), i)
+	}
+	types.bigIntNames[t] = name
+	types.bigIntSpecs[name] = "{}"
+	spec := types.bigIntExpression(model)
+	if spec == "" {
+		types.bigIntNames[t] = ""
+		delete(types.bigIntSpecs, name)
+		return ""
+	}
+	types.bigIntSpecs[name] = spec
+	return fmt.Sprintf("%q", name)
+}
+
+func (types *tsTypeRegistry) bigIntExpression(model *typeModel) string {
+	switch model.kind {
+	case kindList:
+		if elem := types.bigIntSpec(model.elem); elem != "" {
+			return fmt.Sprintf(`["a",%s]`, elem)
+		}
+	case kindMap:
+		if value := types.bigIntSpec(model.elem); value != "" {
+			return fmt.Sprintf(`["m",%s]`, value)
+		}
+	case kindObject:
+		return types.bigIntStructSpec(model.decl)
+	}
+	return ""
+}
+
+func (types *tsTypeRegistry) bigIntStructSpec(decl *typeDecl) string {
+	var fields []string
+	for _, field := range decl.fields {
+		if spec := types.bigIntSpec(field.model); spec != "" {
+			fields = append(fields, fmt.Sprintf("%q:%s", field.name, spec))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(fields, ",") + "}"
+}
+
+// writes where the bigints are inside every named struct along with the functions that turn the received values into bigints
+func (types *tsTypeRegistry) writeBigIntSpecs(builder *strings.Builder) {
+	if types.int64Mode != Int64AsBigInt {
+		return
+	}
+	builder.WriteString("type BigIntSpec = 1 | string | ['a' | 'm', BigIntSpec] | { [field: string]: BigIntSpec }\n")
+	specs := strings.Builder{}
+	specs.WriteString("const bigIntSpecs: Record<string, BigIntSpec> = {")
+	for _, name := range getSortedKeys(types.bigIntSpecs) {
+		specs.WriteString(fmt.Sprintf("%q:%s,", name, types.bigIntSpecs[name]))
+	}
+	specs.WriteString("};")
+	builder.WriteString(formatTS(specs.String(), "") + "\n")
+
+	text := "function reviveBigInts(value: any, spec?: BigIntSpec): any {\n" +
+		"  if (value === null || value === undefined || spec === undefined) return value;\n" +
+		"  if (spec === 1) return typeof value === 'string' || typeof value === 'number' ? BigInt(value) : value;\n" +
+		"  if (typeof spec === 'string') return reviveBigInts(value, bigIntSpecs[spec]);\n" +
+		"  if (Array.isArray(spec)) {\n" +
+		"    const [kind, inner] = spec;\n" +
+		"    if (kind === 'a' && Array.isArray(value)) return value.map((elem) => reviveBigInts(elem, inner));\n" +
+		"    if (kind === 'm' && typeof value === 'object') {\n" +
+		"      for (const key of Object.keys(value)) value[key] = reviveBigInts(value[key], inner);\n" +
+		"    }\n" +
+		"    return value;\n" +
+		"  }\n" +
+		"  if (typeof value === 'object') {\n" +
+		"    for (const key of Object.keys(spec)) {\n" +
+		"      if (key in value) value[key] = reviveBigInts(value[key], spec[key]);\n" +
+		"    }\n" +
+		"  }\n" +
+		"  return value;\n" +
+		"}\n" +
+		"// errorSpecs holds the specs of the data of the declared errors, by status\n" +
+		"async function reviveResponse<T>(response: Promise<RpcResponse<any>>, spec?: BigIntSpec, errorSpecs?: Record<number, BigIntSpec>): Promise<RpcResponse<T>> {\n" +
+		"  const res = await response;\n" +
+		"  if (res.ok) return { ...res, body: reviveBigInts(res.body, spec) };\n" +
+		"  const errorSpec = errorSpecs?.[res.status];\n" +
+		"  return errorSpec === undefined ? res : { ...res, error: { ...res.error, data: reviveBigInts(res.error.data, errorSpec) } };\n" +
+		"}\n" +
+		"function reviveSubscription<T>(subscription: RpcSubscription<any>, spec: BigIntSpec): RpcSubscription<T> {\n" +
+		"  return {\n" +
+		"    async *[Symbol.asyncIterator]() {\n" +
+		"      for await (const event of subscription) yield reviveBigInts(event, spec);\n" +
+		"    },\n" +
+		"    unsubscribe: subscription.unsubscribe,\n" +
+		"  };\n" +
+		"}\n"
+	builder.WriteString(text)
+}
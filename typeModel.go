@@ -0,0 +1,380 @@
+package bluerpc
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// typeModel describes a go type the way it is put on the wire, independently of the language of a client.
+// Every generator (typescript, zod, JSON Schema, the go, python, swift and kotlin clients) translates it into its own types, App.Schema exports it
+type typeModel struct {
+	kind typeKind
+	// the type of the values of optionals, lists and maps
+	elem *typeModel
+	// the type of the keys of maps. They are json strings on the wire, typescript types them after their go type
+	key *typeModel
+	// the declaration of objects and enums
+	decl *typeDecl
+
+	// the go type the model was built from. The languages that declare named lists and maps (typescript, JSON Schema) name them after it
+	goType reflect.Type
+	// how the values are put on the wire when they are not encoded out of their go structure, the kinds alone do not tell a base64 string from a date for example
+	encoding wireEncoding
+	// the typescript type set with OverrideTSType, the model is kindAny for the other languages
+	override string
+	// set on the model that stands for a named list or map inside of itself, map[string]Tree inside of Tree for example. It is kindAny for the languages that can not declare that list or map,
+	// the others refer to it by name
+	cycle *typeModel
+}
+
+type typeKind int
+
+const (
+	kindAny typeKind = iota
+	kindString
+	kindBool
+	kindInt
+	// int64 and uint64 values, sent as json strings with Int64AsString and Int64AsBigInt
+	kindInt64
+	kindFloat
+	// time.Time values, sent as RFC 3339 strings
+	kindTime
+	kindOptional
+	kindList
+	// maps are sent as json objects, their keys are always strings
+	kindMap
+	kindObject
+	kindEnum
+)
+
+// a named type that the clients declare : a struct or a registered enum
+type typeDecl struct {
+	name   string
+	goType reflect.Type
+	// the fields of a struct
+	fields []fieldModel
+	// the values of an enum and the kind they share (string, int, float or bool)
+	values   []any
+	baseKind typeKind
+}
+
+// a field of a struct the way it is put on the wire
+type fieldModel struct {
+	name     string
+	model    *typeModel
+	required bool
+	doc      string
+	// the field is a dynamic slug of the path of the procedure rather than a query parameter
+	slug bool
+	// the rules of the validate tag and the values of the enum tag
+	validate []ValidationRule
+	enum     []string
+}
+
+// the models of a procedure. query, input and output are nil when they are any
+type procedureModel struct {
+	method Method
+	// the http method the procedure is served on, GET, POST or the one set with HTTPMethod
+	httpMethod string
+	path       string
+	query      *typeModel
+	input      *typeModel
+	output     *typeModel
+	// the dynamic slugs of the path that no query field fills, the clients take them as string parameters
+	slugs  []string
+	errors []errorModel
+	docs   procedureDocs
+}
+
+// an error declared with Throws, data is nil when it has no data
+type errorModel struct {
+	code int
+	data *typeModel
+}
+
+type typeModelKey struct {
+	goType reflect.Type
+	// query structs are declared apart from the other structs, their fields are named after the paramName tag.
+	// The fields that fill a dynamic slug depend on the path of the procedure, the slugs are part of the key
+	query bool
+	slugs string
+}
+
+// typeModels builds the models of the types of an app. Every struct and enum is declared once under a name
+// that is unique among the declarations and the names that the client reserves for itself
+type typeModels struct {
+	app        *App
+	decls      []*typeDecl
+	byKey      map[typeModelKey]*typeDecl
+	takenNames map[string]bool
+
+	// the models of the named lists and maps, and the ones that are being built along with the number of structs that were being declared when they started
+	named     map[reflect.Type]*typeModel
+	building  map[reflect.Type]*typeModel
+	declaring map[reflect.Type]int
+	// the number of structs whose fields are being built
+	depth int
+}
+
+// creates the models of the types of an app. The app can be nil
+func newTypeModels(app *App, reservedNames ...string) *typeModels {
+	models := &typeModels{
+		app:        app,
+		byKey:      map[typeModelKey]*typeDecl{},
+		takenNames: map[string]bool{},
+		named:      map[reflect.Type]*typeModel{},
+		building:   map[reflect.Type]*typeModel{},
+		declaring:  map[reflect.Type]int{},
+	}
+	for _, name := range reservedNames {
+		models.takenNames[name] = true
+	}
+	return models
+}
+
+// returns the models of a procedure attached at fullPath
+func (models *typeModels) procedure(proc *ProcedureInfo, fullPath string) procedureModel {
+	procedure := procedureModel{method: proc.method, httpMethod: proc.httpMethod(), path: fullPath, docs: proc.docs}
+	dynamicSlugNames := findDynamicSlugs(fullPath)
+	if !isInterpretedAsEmpty(proc.querySchema) {
+		procedure.query = models.queryModel(getType(proc.querySchema), dynamicSlugNames)
+	}
+	if proc.method == MUTATION && !isInterpretedAsEmpty(proc.inputSchema) {
+		procedure.input = models.model(getType(proc.inputSchema))
+	}
+	if proc.outputSchema != nil && !isInterpretedAsEmpty(proc.outputSchema) {
+		procedure.output = models.model(getType(proc.outputSchema))
+	}
+	for _, slugName := range dynamicSlugNames {
+		if procedure.query == nil || !queryHasField(getType(proc.querySchema), slugName) {
+			procedure.slugs = append(procedure.slugs, slugName)
+		}
+	}
+	for _, procErr := range proc.errors {
+		errModel := errorModel{code: procErr.code}
+		if !isInterpretedAsEmpty(procErr.dataSchema) {
+			errModel.data = models.model(getType(procErr.dataSchema))
+		}
+		procedure.errors = append(procedure.errors, errModel)
+	}
+	return procedure
+}
+
+// returns the model of a go type
+func (models *typeModels) model(t reflect.Type) *typeModel {
+	if t == nil {
+		return &typeModel{kind: kindAny}
+	}
+	if models.app != nil {
+		if tsType, ok := models.app.tsTypeOverrides[t]; ok {
+			return &typeModel{kind: kindAny, goType: t, override: tsType}
+		}
+		if values, ok := models.app.enums[t]; ok {
+			return &typeModel{kind: kindEnum, decl: models.enumDecl(t, values), goType: t}
+		}
+	}
+	encoding := wireEncodingOf(t)
+	switch encoding {
+	case encodedAsTime:
+		return &typeModel{kind: kindTime, goType: t, encoding: encoding}
+	case encodedAsRawJSON, encodedAsNumber:
+		return &typeModel{kind: kindAny, goType: t, encoding: encoding}
+	case encodedAsText, encodedAsBytes:
+		return &typeModel{kind: kindString, goType: t, encoding: encoding}
+	case encodedAsInt64:
+		return &typeModel{kind: kindInt64, goType: t, encoding: encoding}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := models.model(t.Elem())
+		if elem.cycle != nil {
+			return elem
+		}
+		if elem.kind == kindOptional || elem.kind == kindAny {
+			collapsed := *elem
+			collapsed.goType = t
+			return &collapsed
+		}
+		return &typeModel{kind: kindOptional, elem: elem, goType: t}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return models.composite(t)
+	case reflect.Struct:
+		return &typeModel{kind: kindObject, decl: models.structDecl(t), goType: t}
+	case reflect.String:
+		return &typeModel{kind: kindString, goType: t}
+	case reflect.Bool:
+		return &typeModel{kind: kindBool, goType: t}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uintptr:
+		return &typeModel{kind: kindInt, goType: t}
+	case reflect.Float32, reflect.Float64:
+		return &typeModel{kind: kindFloat, goType: t}
+	default:
+		return &typeModel{kind: kindAny, goType: t}
+	}
+}
+
+// returns the model of a list or a map. A named list or map is built once. When it holds itself without going through a struct (type Tree map[string]Tree),
+// the inner occurrence is a cycle model so that the models stay finite for the languages that can not declare it
+func (models *typeModels) composite(t reflect.Type) *typeModel {
+	if t.Name() != "" {
+		if model, ok := models.named[t]; ok {
+			return model
+		}
+		if model, ok := models.building[t]; ok {
+			// through a struct the model is shared as it is, the declaration of the struct stops the languages that walk it
+			if models.depth > models.declaring[t] {
+				return model
+			}
+			return &typeModel{kind: kindAny, goType: t, cycle: model}
+		}
+	}
+	model := &typeModel{kind: kindList, goType: t}
+	if t.Name() != "" {
+		models.building[t] = model
+		models.declaring[t] = models.depth
+		defer func() {
+			delete(models.building, t)
+			delete(models.declaring, t)
+		}()
+	}
+	if t.Kind() == reflect.Map {
+		model.kind = kindMap
+		model.key = models.model(t.Key())
+	}
+	model.elem = models.model(t.Elem())
+	if t.Name() != "" {
+		models.named[t] = model
+	}
+	return model
+}
+
+// returns the model of the query parameters, named the way queryParser reads them (the paramName tag or the field name)
+func (models *typeModels) queryModel(queryType reflect.Type, dynamicSlugNames []string) *typeModel {
+	t := queryType
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	key := typeModelKey{goType: t, query: true, slugs: strings.Join(dynamicSlugNames, "/")}
+	if decl, ok := models.byKey[key]; ok {
+		return &typeModel{kind: kindObject, decl: decl, goType: queryType}
+	}
+	decl := models.declare(key, t)
+	models.depth++
+	defer func() { models.depth-- }()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("paramName")
+		if name == "" {
+			name = field.Name
+		}
+		isSlug := sliceStrContains(dynamicSlugNames, name)
+		decl.fields = append(decl.fields, fieldModel{
+			name:     name,
+			model:    models.model(field.Type),
+			required: isSlug || isFieldRequired(field),
+			doc:      field.Tag.Get("doc"),
+			slug:     isSlug,
+			validate: parseValidateTag(field.Tag.Get("validate")),
+			enum:     enumTagTexts(field),
+		})
+	}
+	return &typeModel{kind: kindObject, decl: decl, goType: queryType}
+}
+
+func (models *typeModels) structDecl(t reflect.Type) *typeDecl {
+	key := typeModelKey{goType: t}
+	if decl, ok := models.byKey[key]; ok {
+		return decl
+	}
+	// the declaration is registered before the fields are expanded so that recursive types refer to themselves
+	decl := models.declare(key, t)
+	models.depth++
+	defer func() { models.depth-- }()
+	for _, field := range wireFields(t) {
+		fieldModel := fieldModel{
+			name:     field.name,
+			model:    models.model(field.field.Type),
+			required: !field.omitEmpty,
+			doc:      field.field.Tag.Get("doc"),
+			validate: parseValidateTag(field.field.Tag.Get("validate")),
+			enum:     enumTagTexts(field.field),
+		}
+		if field.asString {
+			fieldModel.model = &typeModel{kind: kindString, goType: field.field.Type}
+		}
+		decl.fields = append(decl.fields, fieldModel)
+	}
+	return decl
+}
+
+func (models *typeModels) enumDecl(t reflect.Type, values []any) *typeDecl {
+	key := typeModelKey{goType: t}
+	if decl, ok := models.byKey[key]; ok {
+		return decl
+	}
+	decl := models.declare(key, t)
+	decl.values = values
+	switch t.Kind() {
+	case reflect.String:
+		decl.baseKind = kindString
+	case reflect.Bool:
+		decl.baseKind = kindBool
+	case reflect.Float32, reflect.Float64:
+		decl.baseKind = kindFloat
+	default:
+		decl.baseKind = kindInt
+	}
+	return decl
+}
+
+// registers the declaration of a type under a name that no other declaration takes
+func (models *typeModels) declare(key typeModelKey, t reflect.Type) *typeDecl {
+	baseName := toPascalCase(tsTypeName(t))
+	if baseName == "" || baseName[0] < 'A' || baseName[0] > 'Z' {
+		baseName = "Model" + baseName
+	}
+	name := baseName
+	for i := 2; models.takenNames[name]; i++ {
+		name = baseName + strconv.Itoa(i)
+	}
+	models.takenNames[name] = true
+	decl := &typeDecl{name: name, goType: t}
+	models.byKey[key] = decl
+	models.decls = append(models.decls, decl)
+	return decl
+}
+
+// reports if the declaration can contain itself, through its fields or the fields of the declarations it contains
+func (decl *typeDecl) isRecursive() bool {
+	var reaches func(model *typeModel, visited map[*typeDecl]bool) bool
+	reaches = func(model *typeModel, visited map[*typeDecl]bool) bool {
+		switch {
+		case model == nil:
+			return false
+		case model.decl == decl:
+			return true
+		case model.elem != nil:
+			return reaches(model.elem, visited)
+		case model.kind == kindObject && !visited[model.decl]:
+			visited[model.decl] = true
+			for _, field := range model.decl.fields {
+				if reaches(field.model, visited) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	visited := map[*typeDecl]bool{decl: true}
+	for _, field := range decl.fields {
+		if reaches(field.model, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -50,6 +50,24 @@ func splitStringOnSlash(s string) ([]string, error) {
 
 	return result, nil
 }
+
+// findDynamicSlugs returns the names of every dynamic segment of a path, in order. /users/{id}/files/{path...} returns [id, path]
+func findDynamicSlugs(path string) []string {
+	var slugs []string
+	for _, part := range strings.Split(path, "/") {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+		name = strings.TrimSuffix(name, "...")
+		if name == "$" || name == "" {
+			continue
+		}
+		slugs = append(slugs, name)
+	}
+	return slugs
+}
+
 func setField(field reflect.Value, values []string) error {
 	if len(values) == 0 {
 		return nil
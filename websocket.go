@@ -0,0 +1,189 @@
+package bluerpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// the frame type a client sends in order to stop a running subscription
+const stopFrame Method = "stop"
+
+// creates the handler for the websocket endpoint. Every frame that the client sends is a procedureCall.
+// Calls are served concurrently through the app's mux, so they go through the same middlewares and authorizers as plain http calls
+func (a *App) webSocketHandler() http.Handler {
+	return websocket.Server{
+		Handshake: a.checkWebSocketOrigin,
+		Handler:   a.serveWebSocket,
+	}
+}
+
+// the calls of the socket carry the cookies and the authorization of the page that opened it,
+// so only the server's own pages and the CORS_Origin may open it. Clients that are not browsers do not send an origin
+func (a *App) checkWebSocketOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(originUrl.Host, req.Host) {
+		return nil
+	}
+	if a.config.CORS_Origin != "" && strings.EqualFold(strings.TrimSuffix(origin, "/"), strings.TrimSuffix(a.config.CORS_Origin, "/")) {
+		return nil
+	}
+	return fmt.Errorf("the origin %s is not allowed to open the websocket", origin)
+}
+
+// a running subscription of the socket. Its id is chosen by the client, so an entry is only removed by the subscription that added it
+type socketSubscription struct {
+	stop context.CancelFunc
+}
+
+func (a *App) serveWebSocket(conn *websocket.Conn) {
+	defer conn.Close()
+
+	parent := conn.Request()
+	ctx, cancel := context.WithCancel(parent.Context())
+	defer cancel()
+
+	var writeMutex sync.Mutex
+	send := func(result *procedureCallResult) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		websocket.JSON.Send(conn, result)
+	}
+
+	var subscriptionsMutex sync.Mutex
+	subscriptions := map[string]*socketSubscription{}
+
+	for {
+		var call procedureCall
+		if err := websocket.JSON.Receive(conn, &call); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				send(&procedureCallResult{
+					Type:   "error",
+					Status: http.StatusBadRequest,
+					Body:   Map{"message": err.Error()},
+				})
+				continue
+			}
+			// the client went away (io.EOF) or the connection broke
+			return
+		}
+
+		if call.Type == stopFrame {
+			subscriptionsMutex.Lock()
+			if subscription, ok := subscriptions[call.Id]; ok {
+				subscription.stop()
+				delete(subscriptions, call.Id)
+			}
+			subscriptionsMutex.Unlock()
+			continue
+		}
+
+		callCtx, callCancel := context.WithCancel(ctx)
+		var subscription *socketSubscription
+		if call.Type == SUBSCRIPTION {
+			subscriptionsMutex.Lock()
+			_, running := subscriptions[call.Id]
+			if !running {
+				subscription = &socketSubscription{stop: callCancel}
+				subscriptions[call.Id] = subscription
+			}
+			subscriptionsMutex.Unlock()
+			if running {
+				callCancel()
+				send(&procedureCallResult{
+					Id:     call.Id,
+					Type:   "error",
+					Status: http.StatusBadRequest,
+					Body:   Map{"message": fmt.Sprintf("the subscription %q is already running", call.Id)},
+				})
+				continue
+			}
+		}
+
+		go func(call procedureCall) {
+			defer callCancel()
+			a.serveSocketCall(callCtx, parent, &call, send)
+
+			if subscription != nil {
+				subscriptionsMutex.Lock()
+				if subscriptions[call.Id] == subscription {
+					delete(subscriptions, call.Id)
+				}
+				subscriptionsMutex.Unlock()
+			}
+		}(call)
+	}
+}
+
+// serves a single call that came from the websocket and sends its result(s) back.
+// Queries and mutations answer with a single "result" frame. Subscriptions send a "data" frame for every event and finish with a "complete" frame
+func (a *App) serveSocketCall(ctx context.Context, parent *http.Request, call *procedureCall, send func(*procedureCallResult)) {
+	req, err := newCallRequest(ctx, parent, call)
+	if err == nil {
+		err = a.checkCallPath(req)
+	}
+	if err != nil {
+		send(&procedureCallResult{
+			Id:     call.Id,
+			Type:   "error",
+			Status: http.StatusBadRequest,
+			Body:   Map{"message": err.Error()},
+		})
+		return
+	}
+
+	writer := newCallWriter()
+	if call.Type == SUBSCRIPTION {
+		writer.onFlush = func(w *callWriter) {
+			for _, event := range takeServerSentEvents(&w.body) {
+				frameType := "data"
+				if event.name == "error" {
+					frameType = "error"
+				}
+				send(&procedureCallResult{
+					Id:   call.Id,
+					Type: frameType,
+					Body: json.RawMessage(event.data),
+				})
+			}
+		}
+	}
+
+	a.serveMux.ServeHTTP(writer, req)
+
+	if call.Type == SUBSCRIPTION && writer.statusCode() == http.StatusOK {
+		writer.Flush()
+		if ctx.Err() == nil {
+			send(&procedureCallResult{Id: call.Id, Type: "complete"})
+		}
+		return
+	}
+
+	resultType := "result"
+	if call.Type == SUBSCRIPTION {
+		// the subscription was rejected before the stream was opened (authorization, invalid query...)
+		resultType = "error"
+	}
+	send(&procedureCallResult{
+		Id:     call.Id,
+		Type:   resultType,
+		Status: writer.statusCode(),
+		Body:   decodeCallBody(writer.header.Get("Content-Type"), writer.body.Bytes()),
+	})
+}
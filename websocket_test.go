@@ -0,0 +1,148 @@
+package bluerpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	fmt.Println(DefaultColors.Green + "TESTING WEBSOCKET CALLS" + DefaultColors.Reset)
+	app := New(&Config{
+		ValidatorFn:         validate.Struct,
+		DisableGenerateTS:   true,
+		DisableInfoPrinting: true,
+		WebSocketPath:       "/ws",
+		Authorizer: NewAuth(func(ctx *Ctx) (any, error) {
+			if ctx.Get("Authorization") != "Bearer test_token" {
+				return nil, fmt.Errorf("Unauthorized")
+			}
+			return User{Name: "hello"}, nil
+		}),
+	})
+
+	NewQuery(app, func(ctx *Ctx, query test_query) (*Res[procedure_test_output], error) {
+		return &Res[procedure_test_output]{
+			Body: procedure_test_output{
+				FieldOneOut:   query.QueryFirst,
+				FieldTwoOut:   "dwadwa",
+				FieldThreeOut: "dwadwadwa",
+			},
+		}, nil
+	}).Attach(app, "/query")
+
+	NewMutation(app, func(ctx *Ctx, query any, input procedure_test_input) (*Res[procedure_test_output], error) {
+		return &Res[procedure_test_output]{
+			Body: procedure_test_output{
+				FieldOneOut:   input.House,
+				FieldThreeOut: "dwadwadwa",
+			},
+		}, nil
+	}).Protected().Attach(app, "/mutation")
+
+	NewSubscription(app, func(ctx *Ctx, query any, emitter *Emitter[subscription_test_output]) error {
+		for i := 0; i < 3; i++ {
+			if err := emitter.Send(subscription_test_output{Count: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Attach(app, "/events")
+
+	NewSubscription(app, func(ctx *Ctx, query any, emitter *Emitter[subscription_test_output]) error {
+		<-emitter.Done()
+		return nil
+	}).Attach(app, "/wait")
+
+	go app.Listen(":3001")
+	defer app.Shutdown()
+	if err := waitForServerReady(":3001"); err != nil {
+		t.Fatalf(DefaultColors.Red+"Server did not start : %s", err.Error())
+	}
+
+	conn, err := websocket.Dial("ws://localhost:3001/ws", "", "http://localhost:3001")
+	if err != nil {
+		t.Fatalf(DefaultColors.Red+"Could not connect to the websocket : %s", err.Error())
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	send := func(call procedureCall) {
+		if err := websocket.JSON.Send(conn, call); err != nil {
+			t.Fatalf(DefaultColors.Red+"Could not send the frame : %s", err.Error())
+		}
+	}
+	type frame struct {
+		Id     string
+		Type   string
+		Status int
+		Body   json.RawMessage
+	}
+	receive := func() frame {
+		var f frame
+		if err := websocket.JSON.Receive(conn, &f); err != nil {
+			t.Fatalf(DefaultColors.Red+"Could not receive a frame : %s", err.Error())
+		}
+		return f
+	}
+
+	send(procedureCall{Id: "1", Type: QUERY, Path: "/query", Query: map[string]any{"query": "hello"}})
+	res := receive()
+	var output procedure_test_output
+	if err := json.Unmarshal(res.Body, &output); err != nil {
+		t.Fatalf(DefaultColors.Red+"Failed to unmarshal response: %v", err)
+	}
+	if res.Id != "1" || res.Status != 200 || output.FieldOneOut != "hello" {
+		t.Fatalf(DefaultColors.Red+"Unexpected query result %+v", res)
+	}
+
+	send(procedureCall{Id: "2", Type: MUTATION, Path: "/mutation", Input: json.RawMessage(`{"House":"house"}`)})
+	if res := receive(); res.Id != "2" || res.Status != 401 {
+		t.Fatalf(DefaultColors.Red+"Unauthorized mutation was not rejected %+v", res)
+	}
+
+	send(procedureCall{Id: "3", Type: MUTATION, Path: "/mutation", Input: json.RawMessage(`{"House":"house"}`), Headers: map[string]string{"Authorization": "Bearer test_token"}})
+	if res := receive(); res.Id != "3" || res.Status != 200 {
+		t.Fatalf(DefaultColors.Red+"Authorized mutation failed %+v", res)
+	}
+
+	send(procedureCall{Id: "4", Type: SUBSCRIPTION, Path: "/events"})
+	var events []subscription_test_output
+	for {
+		res := receive()
+		if res.Type == "complete" {
+			break
+		}
+		if res.Type != "data" {
+			t.Fatalf(DefaultColors.Red+"Unexpected subscription frame %+v", res)
+		}
+		var event subscription_test_output
+		if err := json.Unmarshal(res.Body, &event); err != nil {
+			t.Fatalf(DefaultColors.Red+"Failed to unmarshal event: %v", err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf(DefaultColors.Red+"Expected 3 subscription events, got %v", events)
+	}
+
+	send(procedureCall{Id: "5", Type: SUBSCRIPTION, Path: "/wait"})
+	send(procedureCall{Id: "5", Type: SUBSCRIPTION, Path: "/wait"})
+	if res := receive(); res.Id != "5" || res.Type != "error" || res.Status != 400 {
+		t.Fatalf(DefaultColors.Red+"Expected a subscription id that is already running to be rejected, got %+v", res)
+	}
+	send(procedureCall{Id: "5", Type: stopFrame})
+
+	if foreign, err := websocket.Dial("ws://localhost:3001/ws", "", "http://evil.example"); err == nil {
+		foreign.Close()
+		t.Fatalf(DefaultColors.Red + "Expected the websocket to refuse a foreign origin")
+	}
+
+	fmt.Println(DefaultColors.Green + "PASSED WEBSOCKET CALLS" + DefaultColors.Reset)
+}
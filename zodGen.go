@@ -0,0 +1,285 @@
+package bluerpc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// returns the zod schema of a model. Every type that the typescript declares by name gets its own exported NameSchema const,
+// wrapped in z.lazy so that the schemas can refer to each other (and to themselves) in any order
+func (types *tsTypeRegistry) zodType(model *typeModel) string {
+	if model == nil {
+		return "z.any()"
+	}
+	if model.cycle != nil {
+		return types.zodType(model.cycle)
+	}
+	if model.kind == kindEnum {
+		tsType := types.tsType(model)
+		if types.inline {
+			return zodLiterals(types, model.decl.values)
+		}
+		return types.zodDeclaration(tsType, func() string { return zodLiterals(types, model.decl.values) })
+	}
+	if tsType, ok := types.mappedType(model); ok {
+		return zodMappedType(tsType)
+	}
+	if model.kind == kindOptional {
+		return types.zodType(model.elem)
+	}
+
+	// the typescript type is generated first so that the type that the schema is annotated with is declared
+	tsType := types.tsType(model)
+	if _, isNamed := types.names[model.goType]; isNamed && model.goType != nil {
+		return types.zodDeclaration(tsType, func() string { return types.zodExpression(model) })
+	}
+	return types.zodExpression(model)
+}
+
+// declares the schema of a named type once and returns the name of its const
+func (types *tsTypeRegistry) zodDeclaration(tsName string, expand func() string) string {
+	schemaName := tsName + "Schema"
+	if _, ok := types.zodDeclarations[schemaName]; ok {
+		return schemaName
+	}
+	// the name is reserved before the schema is expanded so that recursive types refer to their own schema
+	types.zodDeclarations[schemaName] = ""
+	types.zodDeclarations[schemaName] = fmt.Sprintf("export const %s: z.ZodType<%s> = z.lazy(() => %s);", schemaName, tsName, expand())
+	return schemaName
+}
+
+func (types *tsTypeRegistry) zodExpression(model *typeModel) string {
+	switch model.kind {
+	case kindString:
+		return "z.string()"
+	case kindInt, kindInt64:
+		return "z.number().int()"
+	case kindFloat:
+		return "z.number()"
+	case kindBool:
+		return "z.boolean()"
+	case kindList:
+		return fmt.Sprintf("z.array(%s)", types.zodType(model.elem))
+	case kindMap:
+		return fmt.Sprintf("z.record(z.string(), %s)", types.zodType(model.elem))
+	case kindObject:
+		return types.zodObject(model.decl)
+	default:
+		return "z.any()"
+	}
+}
+
+// writes the schema of a struct with the same fields as tsTypeRegistry.object
+func (types *tsTypeRegistry) zodObject(decl *typeDecl) string {
+	builder := strings.Builder{}
+	builder.WriteString("z.object({")
+	for _, field := range decl.fields {
+		schema := zodValidateRules(types.zodField(field), field.validate)
+		if !field.required {
+			schema += ".optional()"
+		}
+		builder.WriteString(fmt.Sprintf(" %s: %s,", tsPropertyName(field.name), schema))
+	}
+	builder.WriteString("})")
+	return builder.String()
+}
+
+// writes the schema of the query parameters with the same fields as tsTypeRegistry.queryObject
+func (types *tsTypeRegistry) zodQueryObject(query *typeModel, slugs []string) string {
+	builder := strings.Builder{}
+	builder.WriteString("z.object({")
+	if query != nil {
+		for _, field := range query.decl.fields {
+			fieldName := field.name
+			schema := zodValidateRules(types.zodField(field), field.validate)
+			if field.slug {
+				fieldName += "Slug"
+			} else if !field.required {
+				schema += ".optional()"
+			}
+			builder.WriteString(fmt.Sprintf(" %s: %s,", tsPropertyName(fieldName), schema))
+		}
+	}
+	for _, slugName := range slugs {
+		builder.WriteString(fmt.Sprintf(" %s: z.string(),", tsPropertyName(slugName+"Slug")))
+	}
+	builder.WriteString("})")
+	return builder.String()
+}
+
+// returns the schema of a field, the union of the values of its enum tag if it has one
+func (types *tsTypeRegistry) zodField(field fieldModel) string {
+	if field.enum == nil {
+		return types.zodType(field.model)
+	}
+	valueModel, isList := enumTagValueModel(field.model)
+	var literals []string
+	for _, value := range field.enum {
+		if types.sendsText(valueModel) {
+			value = strconv.Quote(value)
+		}
+		literals = append(literals, value)
+	}
+	schema := zodUnion(literals)
+	if isList {
+		return fmt.Sprintf("z.array(%s)", schema)
+	}
+	return schema
+}
+
+func zodLiterals(types *tsTypeRegistry, values []any) string {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = types.tsLiteral(reflect.ValueOf(value))
+	}
+	return zodUnion(literals)
+}
+
+// returns the schema that only accepts the given typescript literals
+func zodUnion(literals []string) string {
+	if len(literals) == 1 {
+		return fmt.Sprintf("z.literal(%s)", literals[0])
+	}
+	schemas := make([]string, len(literals))
+	for i, literal := range literals {
+		schemas[i] = fmt.Sprintf("z.literal(%s)", literal)
+	}
+	return fmt.Sprintf("z.union([%s])", strings.Join(schemas, ", "))
+}
+
+// returns the schema of a type that has a mapped typescript type (see mappedType)
+func zodMappedType(tsType string) string {
+	switch tsType {
+	case "string":
+		return "z.string()"
+	case "number":
+		return "z.number()"
+	case "bigint":
+		return "z.bigint()"
+	case "boolean":
+		return "z.boolean()"
+	case "unknown":
+		return "z.unknown()"
+	case "any":
+		return "z.any()"
+	}
+	return fmt.Sprintf("z.custom<%s>()", tsType)
+}
+
+// translates the validate tag of a field into zod. Only the rules that zod has an equivalent for are translated (min, max, len, gt, gte, lt, lte, email, url, uuid and oneof),
+// every rule after dive applies to the elements and is left to the server
+func zodValidateRules(schema string, rules []ValidationRule) string {
+	if len(rules) == 0 {
+		return schema
+	}
+	isString := strings.HasPrefix(schema, "z.string()")
+	isNumber := strings.HasPrefix(schema, "z.number()")
+	isArray := strings.HasPrefix(schema, "z.array(")
+
+	for _, rule := range rules {
+		name, param := rule.Name, rule.Param
+		if name == "dive" {
+			break
+		}
+		// rules that are or-ed together can't be translated into a chain
+		if strings.Contains(name+param, "|") {
+			continue
+		}
+		_, paramErr := strconv.ParseFloat(param, 64)
+		isNumeric := param != "" && paramErr == nil
+
+		switch {
+		case name == "oneof" && (isString || isNumber):
+			var literals []string
+			for _, value := range strings.Fields(param) {
+				if isString {
+					value = strconv.Quote(value)
+				}
+				literals = append(literals, value)
+			}
+			if len(literals) > 0 {
+				schema = zodUnion(literals)
+				isString, isNumber = false, false
+			}
+		case name == "email" && isString:
+			schema += ".email()"
+		case name == "url" && isString:
+			schema += ".url()"
+		case name == "uuid" && isString:
+			schema += ".uuid()"
+		case !isNumeric:
+			continue
+		case (name == "min" || name == "max") && (isString || isNumber || isArray):
+			schema += fmt.Sprintf(".%s(%s)", name, param)
+		case name == "len" && (isString || isArray):
+			schema += fmt.Sprintf(".length(%s)", param)
+		case (name == "gt" || name == "gte" || name == "lt" || name == "lte") && isNumber:
+			schema += fmt.Sprintf(".%s(%s)", name, param)
+		}
+	}
+	return schema
+}
+
+// writes every schema declaration sorted by name followed by the schemas of every procedure. The split client writes the schemas of the procedures in the module of their router instead
+func (types *tsTypeRegistry) writeZodSchemas(builder *strings.Builder) {
+	if !types.zod {
+		return
+	}
+	for _, name := range getSortedKeys(types.zodDeclarations) {
+		builder.WriteString(formatTS(types.zodDeclarations[name], ""))
+		builder.WriteString("\n")
+	}
+	if types.procedureSchemas != nil {
+		writeProcedureSchemas(builder, types.schemasName, types.procedureSchemas)
+	}
+
+	text := "let responseValidation = false;\n" +
+		"// turns the validation of every response body against its schema on or off. It is meant to be turned on in development\n" +
+		"export function setResponseValidation(enabled: boolean) {\n" +
+		"  responseValidation = enabled;\n" +
+		"}\n" +
+		"async function validateResponse<T>(response: Promise<RpcResponse<any>>, schema: z.ZodTypeAny): Promise<RpcResponse<T>> {\n" +
+		"  const res = await response;\n" +
+		"  if (responseValidation && res.ok) res.body = schema.parse(res.body);\n" +
+		"  return res;\n" +
+		"}\n" +
+		"function validateSubscription<T>(subscription: RpcSubscription<any>, schema: z.ZodTypeAny): RpcSubscription<T> {\n" +
+		"  return {\n" +
+		"    async *[Symbol.asyncIterator]() {\n" +
+		"      for await (const event of subscription) yield responseValidation ? schema.parse(event) : event;\n" +
+		"    },\n" +
+		"    unsubscribe: subscription.unsubscribe,\n" +
+		"  };\n" +
+		"}\n"
+	builder.WriteString(text)
+}
+
+// writes the object that holds the schemas of the procedures under their address
+func writeProcedureSchemas(builder *strings.Builder, name string, procedureSchemas map[string]string) {
+	schemas := strings.Builder{}
+	schemas.WriteString("export const " + name + " = {")
+	for _, path := range getSortedKeys(procedureSchemas) {
+		schemas.WriteString(fmt.Sprintf("%q: %s,", path, procedureSchemas[path]))
+	}
+	schemas.WriteString("};")
+	builder.WriteString(formatTS(schemas.String(), "") + "\n")
+}
+
+// records the schemas of a procedure under its address in rpcSchemas and returns the expression of its output schema
+func (types *tsTypeRegistry) addProcedureSchemas(procedure procedureModel) string {
+	if !types.zod {
+		return ""
+	}
+	var parts []string
+	if procedure.query != nil || len(procedure.slugs) > 0 {
+		parts = append(parts, "query: "+types.zodQueryObject(procedure.query, procedure.slugs))
+	}
+	if procedure.input != nil {
+		parts = append(parts, "input: "+types.zodType(procedure.input))
+	}
+	parts = append(parts, "output: "+types.zodType(procedure.output))
+	types.procedureSchemas[procedure.path] = "{" + strings.Join(parts, ", ") + "}"
+	return fmt.Sprintf("%s[%q].output", types.schemasName, procedure.path)
+}